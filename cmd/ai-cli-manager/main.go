@@ -2,14 +2,19 @@ package main
 
 import (
 	"log"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/lpm/ai-cli-manager/src"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(src.RunCLI(os.Args[1:]))
+	}
+
 	p := tea.NewProgram(src.NewModel())
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+}
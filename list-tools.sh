@@ -38,7 +38,7 @@ echo "  make run"
 echo "  # or"
 echo "  ./build/ai-cli-manager"
 echo ""
-echo "After first run, configuration will be saved to:"
-echo "  ~/.ai-cli-manager/tools.json"
+echo "Add or override tools in:"
+echo "  ~/.ai-cli-manager/tools.json (user) or ./ai_tools.json (project)"
 echo ""
 echo "The tools are embedded in the binary and accessible without external JSON files."
\ No newline at end of file
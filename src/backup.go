@@ -0,0 +1,216 @@
+package src
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxConfigBackups is how many backups of a config file are kept
+const maxConfigBackups = 20
+
+const backupTimeFormat = "20060102-150405.000"
+
+// configBackup is a saved version of a client config file
+type configBackup struct {
+	Path string    `json:"path"` // the backup file
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+	seq  int       // orders backups made within the same millisecond
+}
+
+// configBackupDir holds the backups of the config file at path. It is
+// named after the whole path, as project configs share a file name.
+func configBackupDir(path string) string {
+	homeDir, _ := os.UserHomeDir()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(abs, "-"), "-")
+	return filepath.Join(homeDir, ".ai-cli-manager", "backups", name)
+}
+
+// backupConfigFile copies the config file at path to a new timestamped
+// backup and prunes the oldest ones. It does nothing when there is no file.
+func backupConfigFile(path string) error {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dir := configBackupDir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// Backups made within the same millisecond get a -1, -2, ... suffix
+	stamp := time.Now().Format(backupTimeFormat)
+	var dst *os.File
+	for seq := 0; ; seq++ {
+		name := stamp
+		if seq > 0 {
+			name += "-" + strconv.Itoa(seq)
+		}
+		dst, err = os.OpenFile(filepath.Join(dir, name+filepath.Ext(path)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) || seq >= 1000 {
+			return err
+		}
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	backups, err := listConfigBackups(path)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(len(backups), maxConfigBackups):] {
+		os.Remove(backup.Path)
+	}
+	return nil
+}
+
+// listConfigBackups returns the backups of the config file at path, newest
+// first
+func listConfigBackups(path string) ([]configBackup, error) {
+	entries, err := os.ReadDir(configBackupDir(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []configBackup
+	for _, entry := range entries {
+		name := entry.Name()
+		t, seq, ok := parseBackupName(strings.TrimSuffix(name, filepath.Ext(path)))
+		info, err := entry.Info()
+		if !ok || err != nil || entry.IsDir() {
+			continue
+		}
+		backups = append(backups, configBackup{Path: filepath.Join(configBackupDir(path), name), Time: t, Size: info.Size(), seq: seq})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+// parseBackupName reads the time and same-millisecond sequence number from
+// a backup's name without its extension
+func parseBackupName(name string) (time.Time, int, bool) {
+	stamp, suffix := name, ""
+	if len(name) > len(backupTimeFormat) {
+		stamp, suffix = name[:len(backupTimeFormat)], name[len(backupTimeFormat):]
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	if suffix == "" {
+		return t, 0, true
+	}
+	seq, err := strconv.Atoi(strings.TrimPrefix(suffix, "-"))
+	if !strings.HasPrefix(suffix, "-") || err != nil || seq < 1 {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
+
+// writeConfigFile replaces the config file at path with data. The current
+// version is backed up first, and the new one is written to a temporary
+// file that is renamed into place, so the file is never half written. When
+// path is a symlink, the file it points to is replaced and the link kept.
+func writeConfigFile(path string, data []byte) error {
+	if err := backupConfigFile(path); err != nil {
+		return fmt.Errorf("cannot back up %s: %w", path, err)
+	}
+
+	path, err := resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// resolveSymlinks follows path to the file it finally points to. Unlike
+// filepath.EvalSymlinks, a link to a file that does not exist yet resolves
+// to that file rather than failing.
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+}
+
+// restoreConfigBackup puts a backup back in place of the config file at
+// path. The version it replaces is backed up in turn.
+func restoreConfigBackup(path string, backup configBackup) error {
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return err
+	}
+	return writeConfigFile(path, data)
+}
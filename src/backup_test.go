@@ -0,0 +1,115 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteConfigFileKeepsSymlink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	real := filepath.Join(dir, "dotfiles", "config.json")
+	if err := os.MkdirAll(filepath.Dir(real), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(real, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config.json")
+	if err := os.Symlink(filepath.Join("dotfiles", "config.json"), link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := writeConfigFile(link, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("%s was replaced by a regular file", link)
+	}
+	data, err := os.ReadFile(real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("link target = %q, want %q", data, "new")
+	}
+	if info, err := os.Stat(real); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("link target mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestWriteConfigFileDanglingSymlink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	real := filepath.Join(dir, "config.json")
+	link := filepath.Join(dir, "link.json")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := writeConfigFile(link, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(real); err != nil || string(data) != "new" {
+		t.Errorf("link target = %q, %v; want %q", data, err, "new")
+	}
+}
+
+func TestBackupConfigFileSameMillisecond(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("v0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Quick successive writes usually share a millisecond
+	for i := 1; i <= 5; i++ {
+		if err := writeConfigFile(path, []byte{'v', byte('0' + i)}); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	backups, err := listConfigBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 5 {
+		t.Fatalf("got %d backups, want 5", len(backups))
+	}
+	// Newest first: the last write backed up v4
+	for i, backup := range backups {
+		data, err := os.ReadFile(backup.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := string([]byte{'v', byte('4' - i)}); string(data) != want {
+			t.Errorf("backup %d = %q, want %q", i, data, want)
+		}
+	}
+}
+
+func TestParseBackupName(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		seq  int
+		ok   bool
+	}{
+		{"20240102-030405.678", 0, true},
+		{"20240102-030405.678-3", 3, true},
+		{"20240102-030405.678-0", 0, false},
+		{"20240102-030405.678x3", 0, false},
+		{"20240102-030405", 0, false},
+		{"notes", 0, false},
+	} {
+		_, seq, ok := parseBackupName(tc.name)
+		if seq != tc.seq || ok != tc.ok {
+			t.Errorf("parseBackupName(%q) = %d, %v; want %d, %v", tc.name, seq, ok, tc.seq, tc.ok)
+		}
+	}
+}
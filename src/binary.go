@@ -0,0 +1,190 @@
+package src
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// BinaryAsset is the download of a binary method for one platform
+type BinaryAsset struct {
+	OS     string `json:"os"`             // runtime.GOOS value
+	Arch   string `json:"arch,omitempty"` // runtime.GOARCH value; empty matches any
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// forHost returns the method with the URL and checksum of the asset for
+// this host. Methods without assets are returned unchanged.
+func (im InstallMethod) forHost() (InstallMethod, error) {
+	if len(im.Assets) == 0 {
+		return im, nil
+	}
+	for _, asset := range im.Assets {
+		if asset.OS == runtime.GOOS && (asset.Arch == "" || asset.Arch == runtime.GOARCH) {
+			im.URL = asset.URL
+			im.SHA256 = asset.SHA256
+			im.Assets = nil
+			return im, nil
+		}
+	}
+	return im, fmt.Errorf("no download for %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// archiveType returns how the download is packed: "tar.gz", "zip" or "" for
+// a bare executable
+func (im InstallMethod) archiveType() string {
+	if im.Archive != "" {
+		if im.Archive == "none" {
+			return ""
+		}
+		return im.Archive
+	}
+	name := strings.ToLower(path.Base(strings.SplitN(im.URL, "?", 2)[0]))
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// installBinary downloads the method's file, checks its SHA-256 and places
+// the executable in binDir. Nothing is made executable or moved into place
+// before the checksum matches.
+func installBinary(ctx context.Context, tool AITool, method InstallMethod, binDir string, out io.Writer) error {
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return err
+	}
+
+	// Download next to the target so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(binDir, ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	fmt.Fprintf(out, "Downloading %s\n", method.URL)
+	hash := sha256.New()
+	err = download(ctx, method.URL, io.MultiWriter(tmp, hash))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if method.SHA256 == "" {
+		return fmt.Errorf("no sha256 in the catalog for %s, refusing to install it unverified (its sha256 is %s)", method.URL, sum)
+	}
+	if !strings.EqualFold(sum, method.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s: got sha256 %s, expected %s", method.URL, sum, method.SHA256)
+	}
+	fmt.Fprintf(out, "Verified sha256 %s\n", sum)
+
+	name := method.Binary
+	if name == "" {
+		name = tool.CLICommand
+	}
+	executable := tmp.Name()
+	if archive := method.archiveType(); archive != "" {
+		fmt.Fprintf(out, "Extracting %s from the %s archive\n", name, archive)
+		if executable, err = extractBinary(tmp.Name(), archive, name, binDir); err != nil {
+			return err
+		}
+		defer os.Remove(executable)
+	}
+
+	if err := os.Chmod(executable, 0755); err != nil {
+		return err
+	}
+	target := filepath.Join(binDir, tool.CLICommand)
+	fmt.Fprintf(out, "Installing %s\n", target)
+	return os.Rename(executable, target)
+}
+
+// extractBinary copies the file called name out of an archive into a
+// temporary file in dir. name may be a path inside the archive or just the
+// file's base name.
+func extractBinary(archivePath, archive, name, dir string) (string, error) {
+	matches := func(entry string) bool {
+		entry = strings.TrimPrefix(path.Clean(strings.ReplaceAll(entry, `\`, "/")), "./")
+		if strings.Contains(name, "/") {
+			return entry == strings.TrimPrefix(path.Clean(name), "./")
+		}
+		return path.Base(entry) == name || path.Base(entry) == name+".exe"
+	}
+
+	switch archive {
+	case "tar.gz":
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			if header.Typeflag == tar.TypeReg && matches(header.Name) {
+				return writeTemp(dir, tr)
+			}
+		}
+	case "zip":
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		for _, file := range zr.File {
+			if file.Mode().IsRegular() && matches(file.Name) {
+				rc, err := file.Open()
+				if err != nil {
+					return "", err
+				}
+				defer rc.Close()
+				return writeTemp(dir, rc)
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported archive type %q", archive)
+	}
+	return "", fmt.Errorf("%s not found in the archive", name)
+}
+
+func writeTemp(dir string, r io.Reader) (string, error) {
+	f, err := os.CreateTemp(dir, ".extract-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
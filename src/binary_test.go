@@ -0,0 +1,215 @@
+package src
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testExecutable = "#!/bin/sh\necho fake-cli 1.0\n"
+
+// serveFiles stands in for a download host
+func serveFiles(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "fake-1.0/README", Mode: 0644, Size: 5, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hello"))
+	tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(content))
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func zipArchive(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(content))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestInstallBinary(t *testing.T) {
+	tgz := tarGz(t, "fake-1.0/bin/fake", testExecutable)
+	zipped := zipArchive(t, "fake-1.0/fake", testExecutable)
+	bare := []byte(testExecutable)
+	srv := serveFiles(t, map[string][]byte{
+		"/fake.tar.gz": tgz,
+		"/fake.zip":    zipped,
+		"/fake":        bare,
+	})
+
+	tests := []struct {
+		name   string
+		method InstallMethod
+	}{
+		{"tar.gz", InstallMethod{Type: "binary", URL: srv.URL + "/fake.tar.gz", SHA256: sha256Hex(tgz)}},
+		{"zip", InstallMethod{Type: "binary", URL: srv.URL + "/fake.zip", SHA256: sha256Hex(zipped)}},
+		{"path in archive", InstallMethod{Type: "binary", URL: srv.URL + "/fake.tar.gz", Binary: "fake-1.0/bin/fake", SHA256: sha256Hex(tgz)}},
+		{"bare executable", InstallMethod{Type: "binary", URL: srv.URL + "/fake", SHA256: strings.ToUpper(sha256Hex(bare))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binDir := t.TempDir()
+			tool := AITool{Name: "Fake", CLICommand: "fake"}
+			if err := installBinary(context.Background(), tool, tt.method, binDir, io.Discard); err != nil {
+				t.Fatal(err)
+			}
+
+			target := filepath.Join(binDir, "fake")
+			data, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != testExecutable {
+				t.Errorf("installed %q, want %q", data, testExecutable)
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+				t.Errorf("%s is not executable: %v", target, info.Mode())
+			}
+			assertOnlyFiles(t, binDir, "fake")
+		})
+	}
+}
+
+func TestInstallBinaryChecksumMismatch(t *testing.T) {
+	tgz := tarGz(t, "fake", testExecutable)
+	srv := serveFiles(t, map[string][]byte{"/fake.tar.gz": tgz})
+
+	binDir := t.TempDir()
+	method := InstallMethod{Type: "binary", URL: srv.URL + "/fake.tar.gz", SHA256: strings.Repeat("0", 64)}
+	err := installBinary(context.Background(), AITool{Name: "Fake", CLICommand: "fake"}, method, binDir, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("got error %v, want a checksum mismatch", err)
+	}
+	// Nothing may be left behind, executable or not
+	assertOnlyFiles(t, binDir)
+}
+
+func TestInstallBinaryErrors(t *testing.T) {
+	tgz := tarGz(t, "other", testExecutable)
+	srv := serveFiles(t, map[string][]byte{"/other.tar.gz": tgz})
+
+	tests := []struct {
+		name   string
+		method InstallMethod
+		want   string
+	}{
+		{"not found", InstallMethod{Type: "binary", URL: srv.URL + "/missing"}, "404"},
+		{"missing from archive", InstallMethod{Type: "binary", URL: srv.URL + "/other.tar.gz", SHA256: sha256Hex(tgz)}, "fake not found in the archive"},
+		{"wrong archive type", InstallMethod{Type: "binary", URL: srv.URL + "/other.tar.gz", SHA256: sha256Hex(tgz), Archive: "zip"}, "zip"},
+		{"no sha256", InstallMethod{Type: "binary", URL: srv.URL + "/other.tar.gz"}, "no sha256 in the catalog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binDir := t.TempDir()
+			err := installBinary(context.Background(), AITool{Name: "Fake", CLICommand: "fake"}, tt.method, binDir, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one mentioning %q", err, tt.want)
+			}
+			assertOnlyFiles(t, binDir)
+		})
+	}
+}
+
+func TestInstallManagedBinaryShim(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shims are scripts on Windows")
+	}
+	t.Setenv("HOME", t.TempDir())
+	tgz := tarGz(t, "fake", testExecutable)
+	srv := serveFiles(t, map[string][]byte{"/fake.tar.gz": tgz})
+
+	tool := AITool{Name: "Fake CLI", CLICommand: "fake"}
+	method := InstallMethod{Type: "binary", URL: srv.URL + "/fake.tar.gz", SHA256: sha256Hex(tgz)}
+	prefix := managedToolDir(tool)
+	if err := runInstallMethod(context.Background(), tool, method, prefix, io.Discard, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	executable := filepath.Join(prefix, "bin", "fake")
+	target, err := shimTarget(shimPath("fake"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != executable {
+		t.Errorf("shim points at %s, want %s", target, executable)
+	}
+	if got, err := lookCommand("fake"); err != nil || got != shimPath("fake") {
+		t.Errorf("lookCommand(fake) = %q, %v; want the shim", got, err)
+	}
+}
+
+func TestInstallManagedBinaryFailureRemovesPrefix(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := serveFiles(t, map[string][]byte{"/fake": []byte(testExecutable)})
+
+	tool := AITool{Name: "Fake CLI", CLICommand: "fake"}
+	method := InstallMethod{Type: "binary", URL: srv.URL + "/fake", SHA256: strings.Repeat("0", 64)}
+	prefix := managedToolDir(tool)
+	if err := runInstallMethod(context.Background(), tool, method, prefix, io.Discard, nil); err == nil {
+		t.Fatal("install succeeded despite the checksum mismatch")
+	}
+	if _, err := os.Stat(prefix); !os.IsNotExist(err) {
+		t.Errorf("prefix %s was left behind: %v", prefix, err)
+	}
+	if _, err := os.Lstat(shimPath("fake")); !os.IsNotExist(err) {
+		t.Errorf("shim was created: %v", err)
+	}
+}
+
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Errorf("%s contains %v, want %v", dir, got, names)
+	}
+}
@@ -0,0 +1,149 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// catalogChange describes how a shipped tool changed since the last merge
+type catalogChange struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"` // "added", "removed" or "changed"
+	Fields     []string `json:"fields,omitempty"`
+	Overridden []string `json:"overridden,omitempty"` // changed fields the user layer overrides
+}
+
+// diffUpstreamCatalog compares the builtin catalog against the snapshot taken
+// at the last merge. It reports false when no merge has been recorded yet.
+func diffUpstreamCatalog() ([]catalogChange, bool, error) {
+	snapshot, err := os.ReadFile(upstreamSnapshotPath())
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	previous, err := parseCatalogEntries(snapshot)
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", upstreamSnapshotPath(), err)
+	}
+	current, err := parseCatalogEntries(builtinCatalog)
+	if err != nil {
+		return nil, true, err
+	}
+
+	userFields := make(map[string]map[string]json.RawMessage)
+	if data, err := os.ReadFile(userToolsPath()); err == nil && !isLegacyCatalogCopy(data) {
+		if entries, err := parseCatalogEntries(data); err == nil {
+			for _, fields := range entries {
+				userFields[entryName(fields)] = fields
+			}
+		}
+	}
+
+	previousByName := make(map[string]map[string]json.RawMessage)
+	for _, fields := range previous {
+		previousByName[entryName(fields)] = fields
+	}
+
+	var changes []catalogChange
+	seen := make(map[string]bool)
+	for _, fields := range current {
+		name := entryName(fields)
+		seen[name] = true
+
+		old, ok := previousByName[name]
+		if !ok {
+			changes = append(changes, catalogChange{Name: name, Kind: "added"})
+			continue
+		}
+
+		changed := changedFields(old, fields)
+		if len(changed) == 0 {
+			continue
+		}
+		change := catalogChange{Name: name, Kind: "changed", Fields: changed}
+		for _, field := range changed {
+			if _, ok := userFields[name][field]; ok {
+				change.Overridden = append(change.Overridden, field)
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	for _, fields := range previous {
+		if name := entryName(fields); !seen[name] {
+			changes = append(changes, catalogChange{Name: name, Kind: "removed"})
+		}
+	}
+
+	return changes, true, nil
+}
+
+func changedFields(old, current map[string]json.RawMessage) []string {
+	keys := make(map[string]bool)
+	for key := range old {
+		keys[key] = true
+	}
+	for key := range current {
+		keys[key] = true
+	}
+
+	var changed []string
+	for key := range keys {
+		if !jsonEqual(old[key], current[key]) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if len(a) > 0 && json.Compact(&ca, a) != nil {
+		return false
+	}
+	if len(b) > 0 && json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// recordUpstreamMerge snapshots the builtin catalog as the new merge base
+func recordUpstreamMerge() error {
+	path := upstreamSnapshotPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, builtinCatalog, 0644)
+}
+
+func writeCatalogChanges(w io.Writer, changes []catalogChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "The shipped catalog has not changed since the last merge.")
+		return
+	}
+
+	for _, change := range changes {
+		switch change.Kind {
+		case "added":
+			fmt.Fprintf(w, "+ %s (added upstream)\n", change.Name)
+		case "removed":
+			fmt.Fprintf(w, "- %s (removed upstream)\n", change.Name)
+		default:
+			line := fmt.Sprintf("~ %s: %s", change.Name, strings.Join(change.Fields, ", "))
+			if len(change.Overridden) > 0 {
+				line += fmt.Sprintf(" (overridden by user: %s)", strings.Join(change.Overridden, ", "))
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
@@ -0,0 +1,794 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+type installResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "installed", "upgraded", "uninstalled", "skipped", "failed", "blocked" or "cancelled"
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Log    string `json:"log,omitempty"`
+}
+
+type toolStatus struct {
+	Name       string   `json:"name"`
+	CLICommand string   `json:"cli_command"`
+	Installed  bool     `json:"installed"`
+	Version    string   `json:"version,omitempty"`
+	Latest     string   `json:"latest,omitempty"`
+	Outdated   bool     `json:"outdated"`
+	Locked     string   `json:"locked,omitempty"`
+	LockDrift  bool     `json:"lock_drift"`
+	MCPServers int      `json:"mcp_servers"`
+	Source     string   `json:"source"`
+	Missing    []string `json:"missing_prerequisites,omitempty"`
+}
+
+// RunCLI runs a headless subcommand and returns the process exit code
+func RunCLI(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return runList(args[1:])
+	case "install":
+		return runInstallCommand(args[1:])
+	case "upgrade":
+		return runUpgradeCommand(args[1:])
+	case "uninstall":
+		return runUninstallCommand(args[1:])
+	case "catalog":
+		return runCatalogCommand(args[1:])
+	case "lock":
+		return runLockCommand(args[1:])
+	case "validate":
+		return runValidate(args[1:])
+	case "doctor":
+		return runDoctor(args[1:])
+	case "mcp":
+		return runMCPCommand(args[1:])
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
+	printUsage(os.Stderr)
+	return 2
+}
+
+// parseFlags parses fs while allowing flags and positional arguments to be
+// interleaved, and returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// loadCLICatalog loads the merged catalog, printing any problems to stderr
+func loadCLICatalog() ([]AITool, bool) {
+	tools, issues := loadCatalog()
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", issue)
+	}
+	if len(tools) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No tools found in the tool catalog")
+		return nil, false
+	}
+	return tools, true
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage: ai-cli-manager [command] [flags]
+
+Without a command the interactive TUI is started.
+
+Commands:
+  list      List tools with their installation status
+  install   Install tools by name, or --all / --missing
+  upgrade   Upgrade installed tools by name, or --all
+  uninstall Remove tools by name
+  lock      Write ai-tools.lock with the installed versions and methods
+  validate  Check catalog files for errors
+  doctor    Check that managed installs are on PATH
+  mcp       MCP client configs: "mcp plan", "mcp backups" or "mcp restore <client> <n>"
+  catalog   Inspect the shipped catalog: "catalog diff" or "catalog merge"
+  help      Show this help
+`)
+}
+
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table, json or yaml")
+	latest := fs.Bool("latest", false, "look up the latest available versions to find outdated tools")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	tools, ok := loadCLICatalog()
+	if !ok {
+		return 1
+	}
+
+	for i := range tools {
+		tools[i].Installed, tools[i].Version = detectTool(tools[i])
+	}
+	checkPrerequisites(tools)
+	if *latest {
+		versions := checkLatestVersions(tools)
+		for i := range tools {
+			tools[i].Latest = versions[tools[i].Name]
+		}
+	}
+
+	lock, err := loadLock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	statuses := make([]toolStatus, 0, len(tools))
+	for _, tool := range tools {
+		locked, drift := lockDrift(tool, lock)
+		statuses = append(statuses, toolStatus{
+			Name:       tool.Name,
+			CLICommand: tool.CLICommand,
+			Installed:  tool.Installed,
+			Version:    tool.Version,
+			Latest:     tool.Latest,
+			Outdated:   isOutdated(tool),
+			Locked:     locked,
+			LockDrift:  drift,
+			MCPServers: len(tool.MCPServers),
+			Source:     tool.Source,
+			Missing:    tool.Missing,
+		})
+	}
+
+	switch *format {
+	case "table":
+		err = writeStatusTable(os.Stdout, statuses)
+	case "json":
+		err = writeStatusJSON(os.Stdout, statuses)
+	case "yaml":
+		err = writeStatusYAML(os.Stdout, statuses)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (expected table, json or yaml)\n", *format)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeStatusTable(w io.Writer, statuses []toolStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCOMMAND\tSTATUS\tVERSION\tLATEST\tMCP\tSOURCE")
+	for _, s := range statuses {
+		status := "missing"
+		if s.LockDrift {
+			status = "lock drift (" + s.Locked + ")"
+		} else if s.Outdated {
+			status = "outdated"
+		} else if s.Installed {
+			status = "installed"
+		} else if len(s.Missing) > 0 {
+			status = "missing prerequisite"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", s.Name, s.CLICommand, status, orDash(s.Version), orDash(s.Latest), s.MCPServers, s.Source)
+	}
+	return tw.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func writeStatusJSON(w io.Writer, statuses []toolStatus) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(statuses)
+}
+
+func writeStatusYAML(w io.Writer, statuses []toolStatus) error {
+	if len(statuses) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	for _, s := range statuses {
+		_, err := fmt.Fprintf(w, "- name: %s\n  cli_command: %s\n  installed: %t\n  version: %s\n  latest: %s\n  outdated: %t\n  locked: %s\n  lock_drift: %t\n  mcp_servers: %d\n  source: %s\n",
+			strconv.Quote(s.Name), strconv.Quote(s.CLICommand), s.Installed, strconv.Quote(s.Version), strconv.Quote(s.Latest), s.Outdated,
+			strconv.Quote(s.Locked), s.LockDrift, s.MCPServers, strconv.Quote(s.Source))
+		if err != nil {
+			return err
+		}
+		if len(s.Missing) > 0 {
+			fmt.Fprintln(w, "  missing_prerequisites:")
+			for _, missing := range s.Missing {
+				fmt.Fprintf(w, "    - %s\n", strconv.Quote(missing))
+			}
+		}
+	}
+	return nil
+}
+
+func runInstallCommand(args []string) int {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	all := fs.Bool("all", false, "install every tool in the catalog")
+	missing := fs.Bool("missing", false, "install every tool that is not installed yet")
+	force := fs.Bool("force", false, "reinstall tools that are already installed")
+	locked := fs.Bool("locked", false, "install the exact versions and methods recorded in ai-tools.lock")
+	config := loadAppConfig()
+	jobs := fs.Int("jobs", config.Parallelism, "number of tools to install concurrently")
+	timeout := fs.Duration("timeout", 0, "time limit for each tool, overriding the catalog and config.json (0 disables it)")
+	totalTimeout := fs.Duration("total-timeout", parseTimeout(config.TotalTimeout, 0), "time limit for the whole run (0 disables it)")
+	dryRun := fs.Bool("dry-run", false, "show what would be installed and run, without doing it")
+	format := fs.String("format", "json", "summary format: json or table")
+	mcpConfig := fs.String("mcp-config", "", "Claude Desktop config file to use instead of the detected one")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	toolTimeout := config.toolTimeout
+	if flagSet(fs, "timeout") {
+		toolTimeout = func(AITool) time.Duration { return *timeout }
+	}
+
+	if *format != "json" && *format != "table" {
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (expected json or table)\n", *format)
+		return 2
+	}
+	if len(names) == 0 && !*all && !*missing && !*locked {
+		fmt.Fprintln(os.Stderr, "Usage: ai-cli-manager install <name>... | --all | --missing | --locked")
+		return 2
+	}
+
+	tools, ok := loadCLICatalog()
+	if !ok {
+		return 1
+	}
+
+	var lock *lockFile
+	if *locked {
+		if lock, err = loadLock(); err != nil || lock == nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot use --locked: %v\n", orMissing(err, lockPath()))
+			return 1
+		}
+		// Without an explicit selection, install everything in the lock
+		if len(names) == 0 && !*all && !*missing {
+			for name := range lock.Tools {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+	}
+
+	var selected []AITool
+	seen := make(map[string]bool)
+	add := func(tool AITool) {
+		if !seen[tool.Name] {
+			seen[tool.Name] = true
+			selected = append(selected, tool)
+		}
+	}
+	for _, name := range names {
+		i, ok := findTool(tools, name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown tool: %s\n", name)
+			return 2
+		}
+		add(tools[i])
+	}
+	for _, tool := range tools {
+		if *all || (*missing && !isInstalled(tool)) {
+			add(tool)
+		}
+	}
+
+	results := make(map[string]installResult)
+	var queue []AITool
+	for _, tool := range selected {
+		installed, version := detectTool(tool)
+		reinstall := *force
+
+		if lock != nil {
+			entry, ok := lock.Tools[tool.Name]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "✗ %s is not in %s\n", tool.Name, lockPath())
+				results[tool.Name] = installResult{Name: tool.Name, Status: "failed", Error: "not in lockfile"}
+				continue
+			}
+			detected := tool
+			detected.Installed, detected.Version = installed, version
+			if locked, drifted := lockDrift(detected, lock); drifted {
+				fmt.Fprintf(os.Stderr, "%s %s differs from the locked %s\n", tool.Name, version, locked)
+				reinstall = true
+			}
+			if tool, err = lockedTool(tool, entry); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ Cannot install the locked %s: %v\n", tool.Name, err)
+				results[tool.Name] = installResult{Name: tool.Name, Status: "failed", Error: err.Error()}
+				continue
+			}
+		}
+
+		if installed && !reinstall {
+			fmt.Fprintf(os.Stderr, "%s is already installed, skipping\n", tool.Name)
+			results[tool.Name] = installResult{Name: tool.Name, Status: "skipped"}
+			continue
+		}
+		queue = append(queue, tool)
+	}
+
+	if *dryRun {
+		plans := planBatch(queue, tools, mcpTargets(resolveMCPConfig(*mcpConfig).path))
+		if err := writePlans(os.Stdout, *format, plans); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	queue, blocked := prepareInstall(queue, tools)
+	for _, tool := range selected {
+		if reason, ok := blocked[tool.Name]; ok {
+			fmt.Fprintf(os.Stderr, "✗ Cannot install %s: %s\n", tool.Name, reason)
+			results[tool.Name] = installResult{Name: tool.Name, Status: "blocked", Error: reason}
+		}
+	}
+
+	ctx, cancel := interruptContext(context.Background())
+	defer cancel()
+	ctx, cancelTotal := withTimeout(ctx, *totalTimeout)
+	defer cancelTotal()
+
+	events := make(chan installEvent)
+	review := terminalReviewer()
+	install := func(ctx context.Context, tool AITool, out io.Writer) error {
+		return runInstall(ctx, tool, out, review)
+	}
+	go runInstallPool(ctx, queue, *jobs, toolTimeout, install, events)
+
+	for event := range events {
+		switch event.state {
+		case "installing":
+			fmt.Fprintf(os.Stderr, "Installing %s...\n", event.tool.Name)
+		case "output":
+			// Output of concurrent installs is prefixed with the tool name
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", event.tool.Name, event.line)
+		case "failed":
+			fmt.Fprintf(os.Stderr, "✗ Failed to install %s: %v (log: %s)\n", event.tool.Name, event.err, event.log)
+			results[event.tool.Name] = installResult{Name: event.tool.Name, Status: "failed", Error: event.err.Error(), Log: event.log}
+		case "blocked":
+			fmt.Fprintf(os.Stderr, "✗ Cannot install %s: %v\n", event.tool.Name, event.err)
+			results[event.tool.Name] = installResult{Name: event.tool.Name, Status: "blocked", Error: event.err.Error()}
+		case "installed":
+			fmt.Fprintf(os.Stderr, "✓ %s installed successfully!\n", event.tool.Name)
+			results[event.tool.Name] = installResult{Name: event.tool.Name, Status: "installed", Log: event.log}
+		case "cancelled":
+			fmt.Fprintf(os.Stderr, "✗ Cancelled %s\n", event.tool.Name)
+			results[event.tool.Name] = installResult{Name: event.tool.Name, Status: "cancelled", Error: describeCancel(ctx), Log: event.log}
+		}
+	}
+
+	// Report in the order the tools were selected
+	summary := make([]installResult, 0, len(selected))
+	var failed []string
+	for _, tool := range selected {
+		result := results[tool.Name]
+		if result.Status == "failed" || result.Status == "blocked" || result.Status == "cancelled" {
+			failed = append(failed, tool.Name)
+		}
+		summary = append(summary, result)
+	}
+
+	if err := writeResults(os.Stdout, *format, summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "Failed to install: %s\n", strings.Join(failed, ", "))
+		return 1
+	}
+	return 0
+}
+
+// interruptContext returns a context that is cancelled on Ctrl+C or
+// SIGTERM. Running commands are then terminated; a second Ctrl+C kills the
+// manager itself.
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "Cancelling... press Ctrl+C again to quit immediately")
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+// describeCancel explains why ctx was cancelled
+func describeCancel(ctx context.Context) string {
+	if ctx.Err() == context.DeadlineExceeded {
+		return "total timeout exceeded"
+	}
+	return "cancelled"
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// orMissing describes why an optional file could not be used
+func orMissing(err error, path string) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s not found", path)
+}
+
+func writeResults(w io.Writer, format string, results []installResult) error {
+	if format == "table" {
+		return writeInstallTable(w, results)
+	}
+	if results == nil {
+		results = []installResult{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+func writeInstallTable(w io.Writer, results []installResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS\tVERSION\tERROR")
+	for _, r := range results {
+		version := ""
+		if r.From != "" || r.To != "" {
+			version = describeUpgrade(r.From, r.To)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, r.Status, version, r.Error)
+	}
+	return tw.Flush()
+}
+
+func runCatalogCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ai-cli-manager catalog diff|merge")
+		return 2
+	}
+
+	switch args[0] {
+	case "diff":
+		fs := flag.NewFlagSet("catalog diff", flag.ContinueOnError)
+		format := fs.String("format", "text", "output format: text or json")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		changes, merged, err := diffUpstreamCatalog()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !merged {
+			fmt.Fprintln(os.Stderr, "No previous merge recorded. Run \"ai-cli-manager catalog merge\" to record one.")
+			return 1
+		}
+
+		if *format == "json" {
+			if changes == nil {
+				changes = []catalogChange{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(changes); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+		writeCatalogChanges(os.Stdout, changes)
+		return 0
+
+	case "merge":
+		if err := recordUpstreamMerge(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println("Recorded the shipped catalog as merged.")
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown catalog command: %s\n", args[0])
+	return 2
+}
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	var issues []validationIssue
+	if len(files) == 0 {
+		// Validate the active layers as they are merged at startup
+		_, issues = loadCatalog()
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if below, ok := overrideBase(file); ok {
+			issues = append(issues, validateOverrideFile(file, data, below)...)
+		} else {
+			issues = append(issues, validateCatalogFile(file, data)...)
+		}
+	}
+
+	if *format == "json" {
+		if issues == nil {
+			issues = []validationIssue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(issues)
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) == 0 {
+			fmt.Println("Catalog is valid.")
+		}
+	}
+
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runUninstallCommand(args []string) int {
+	fs := flag.NewFlagSet("uninstall", flag.ContinueOnError)
+	removeMCP := fs.Bool("remove-mcp", false, "also remove the tools' MCP servers from every client's config")
+	mcpConfig := fs.String("mcp-config", "", "Claude Desktop config file to use instead of the detected one")
+	format := fs.String("format", "json", "summary format: json or table")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	if *format != "json" && *format != "table" {
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (expected json or table)\n", *format)
+		return 2
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ai-cli-manager uninstall <name>... [--remove-mcp] [--mcp-config <file>]")
+		return 2
+	}
+
+	tools, ok := loadCLICatalog()
+	if !ok {
+		return 1
+	}
+
+	var selected []AITool
+	for _, name := range names {
+		i, ok := findTool(tools, name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown tool: %s\n", name)
+			return 2
+		}
+		selected = append(selected, tools[i])
+	}
+
+	ctx, cancel := interruptContext(context.Background())
+	defer cancel()
+	config := loadAppConfig()
+
+	targets := mcpTargets(resolveMCPConfig(*mcpConfig).path)
+	var results []installResult
+	var failed []string
+	for _, tool := range selected {
+		if ctx.Err() != nil {
+			results = append(results, installResult{Name: tool.Name, Status: "cancelled"})
+			failed = append(failed, tool.Name)
+			continue
+		}
+		if !isInstalled(tool) {
+			fmt.Fprintf(os.Stderr, "%s is not installed, skipping\n", tool.Name)
+			results = append(results, installResult{Name: tool.Name, Status: "skipped"})
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Uninstalling %s...\n", tool.Name)
+		toolCtx, cancelTool := withTimeout(ctx, config.toolTimeout(tool))
+		err := runUninstall(toolCtx, tool, os.Stderr)
+		cancelTool()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Failed to uninstall %s: %v\n", tool.Name, err)
+			results = append(results, installResult{Name: tool.Name, Status: "failed", Error: err.Error()})
+			failed = append(failed, tool.Name)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "✓ %s uninstalled\n", tool.Name)
+		results = append(results, installResult{Name: tool.Name, Status: "uninstalled"})
+
+		if entries := configuredMCPServers(targets, tool); len(entries) > 0 {
+			if !*removeMCP {
+				fmt.Fprintf(os.Stderr, "%s still has MCP servers: %s; rerun with --remove-mcp to remove them\n", tool.Name, strings.Join(entries, ", "))
+				continue
+			}
+			if _, err := removeMCPServers(targets, tool); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ Failed to remove MCP servers of %s: %v\n", tool.Name, err)
+				failed = append(failed, tool.Name)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "✓ Removed MCP servers: %s\n", strings.Join(entries, ", "))
+		}
+	}
+
+	if err := writeResults(os.Stdout, *format, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "Failed to uninstall: %s\n", strings.Join(failed, ", "))
+		return 1
+	}
+	return 0
+}
+
+func runUpgradeCommand(args []string) int {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	all := fs.Bool("all", false, "upgrade every installed tool")
+	format := fs.String("format", "json", "summary format: json or table")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	if *format != "json" && *format != "table" {
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (expected json or table)\n", *format)
+		return 2
+	}
+	if len(names) == 0 && !*all {
+		fmt.Fprintln(os.Stderr, "Usage: ai-cli-manager upgrade <name>... | --all")
+		return 2
+	}
+
+	tools, ok := loadCLICatalog()
+	if !ok {
+		return 1
+	}
+
+	var selected []AITool
+	if *all {
+		selected = tools
+	} else {
+		for _, name := range names {
+			i, ok := findTool(tools, name)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown tool: %s\n", name)
+				return 2
+			}
+			selected = append(selected, tools[i])
+		}
+	}
+
+	ctx, cancel := interruptContext(context.Background())
+	defer cancel()
+	config := loadAppConfig()
+	review := terminalReviewer()
+
+	var results []installResult
+	var failed []string
+	for _, tool := range selected {
+		if ctx.Err() != nil {
+			results = append(results, installResult{Name: tool.Name, Status: "cancelled"})
+			failed = append(failed, tool.Name)
+			continue
+		}
+		if !isInstalled(tool) {
+			if !*all {
+				fmt.Fprintf(os.Stderr, "%s is not installed, skipping\n", tool.Name)
+				results = append(results, installResult{Name: tool.Name, Status: "skipped"})
+			}
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Upgrading %s...\n", tool.Name)
+		toolCtx, cancelTool := withTimeout(ctx, config.toolTimeout(tool))
+		before, after, err := runUpgrade(toolCtx, tool, os.Stderr, review)
+		cancelTool()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Failed to upgrade %s: %v\n", tool.Name, err)
+			results = append(results, installResult{Name: tool.Name, Status: "failed", From: before, Error: err.Error()})
+			failed = append(failed, tool.Name)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "✓ %s upgraded: %s\n", tool.Name, describeUpgrade(before, after))
+		results = append(results, installResult{Name: tool.Name, Status: "upgraded", From: before, To: after})
+	}
+
+	if err := writeResults(os.Stdout, *format, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "Failed to upgrade: %s\n", strings.Join(failed, ", "))
+		return 1
+	}
+	return 0
+}
+
+func runLockCommand(args []string) int {
+	fs := flag.NewFlagSet("lock", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	tools, ok := loadCLICatalog()
+	if !ok {
+		return 1
+	}
+
+	lock := generateLock(tools, os.Stderr)
+	if err := writeLock(lock); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Locked %d tools in %s\n", len(lock.Tools), lockPath())
+	return 0
+}
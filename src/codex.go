@@ -0,0 +1,668 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// codexConfigPath is Codex's config file, under $CODEX_HOME or ~/.codex
+func codexConfigPath() string {
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return filepath.Join(dir, "config.toml")
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".codex", "config.toml")
+}
+
+// codexTarget keeps servers in the [mcp_servers.<name>] tables of Codex's
+// TOML config. Only those tables are rewritten; other settings, comments
+// and unknown keys of a server are kept as they are. Servers written as
+// inline tables or dotted keys are read, but the file is then not written.
+type codexTarget struct {
+	path string
+}
+
+func (t codexTarget) ID() string   { return "codex" }
+func (t codexTarget) App() string  { return "Codex" }
+func (t codexTarget) Path() string { return t.path }
+
+func (t codexTarget) load() (*tomlDocument, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseTOML(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (t codexTarget) Read() (map[string]MCPServerEntry, error) {
+	doc, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make(map[string]MCPServerEntry)
+	for _, table := range doc.tables {
+		if _, ok := table.server(); !ok || len(table.path) > 3 {
+			continue
+		}
+		name := table.path[1]
+		entry := servers[name]
+		if len(table.path) == 3 {
+			if table.path[2] == "env" {
+				entry.Env = stringMap(table.values())
+				servers[name] = entry
+			}
+			continue
+		}
+		env := entry.Env
+		entry = codexEntry(table.values())
+		if entry.Env == nil {
+			entry.Env = env
+		}
+		servers[name] = entry
+	}
+	for name, values := range doc.keyedServers() {
+		servers[name] = codexEntry(values)
+	}
+	return servers, nil
+}
+
+// codexEntry reads a server from the values of its table
+func codexEntry(values map[string]interface{}) MCPServerEntry {
+	var entry MCPServerEntry
+	entry.Command, _ = values["command"].(string)
+	if args, ok := values["args"].([]interface{}); ok {
+		for _, arg := range args {
+			if s, ok := arg.(string); ok {
+				entry.Args = append(entry.Args, s)
+			}
+		}
+	}
+	if env, ok := values["env"].(map[string]interface{}); ok {
+		entry.Env = stringMap(env)
+	}
+	return entry
+}
+
+func (t codexTarget) Write(set map[string]MCPServerEntry, remove []string) error {
+	doc, err := t.load()
+	if os.IsNotExist(err) {
+		doc, err = &tomlDocument{}, nil
+	}
+	if err != nil {
+		return refuseWrite(t.path, err)
+	}
+	if keyed := doc.keyedServers(); len(keyed) > 0 {
+		// Adding [mcp_servers.<name>] tables next to these could define a
+		// server twice, which makes the whole file invalid
+		return refuseWrite(t.path, fmt.Errorf("MCP servers %s are written as inline tables or dotted keys; move them to [mcp_servers.<name>] tables to let them be managed", strings.Join(sortedKeys(keyed), ", ")))
+	}
+
+	removed := make(map[string]bool)
+	for _, name := range remove {
+		removed[name] = true
+	}
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(doc.text[:doc.preambleEnd])
+	written := make(map[string]bool)
+	for _, table := range doc.tables {
+		name, isServer := table.server()
+		_, replace := set[name]
+		switch {
+		case !isServer:
+			b.WriteString(table.text(doc.text))
+		case removed[name] && !replace:
+		case replace && len(table.path) == 2:
+			b.WriteString(table.header(doc.text))
+			writeCodexEntry(&b, set[name])
+			b.WriteString(table.withoutKeys(doc.text, "command", "args", "env"))
+			written[name] = true
+		case replace && len(table.path) == 3 && table.path[2] == "env":
+			// Replaced by the inline env of the entry
+		default:
+			b.WriteString(table.text(doc.text))
+		}
+	}
+
+	for _, name := range names {
+		if written[name] {
+			continue
+		}
+		text := b.String()
+		if text != "" && !strings.HasSuffix(text, "\n\n") {
+			if !strings.HasSuffix(text, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[mcp_servers.%s]\n", tomlKey(name))
+		writeCodexEntry(&b, set[name])
+	}
+
+	return writeConfigFile(t.path, []byte(b.String()))
+}
+
+func writeCodexEntry(b *strings.Builder, entry MCPServerEntry) {
+	fmt.Fprintf(b, "command = %s\n", tomlString(entry.Command))
+	if len(entry.Args) > 0 {
+		args := make([]string, len(entry.Args))
+		for i, arg := range entry.Args {
+			args[i] = tomlString(arg)
+		}
+		fmt.Fprintf(b, "args = [%s]\n", strings.Join(args, ", "))
+	}
+	if len(entry.Env) > 0 {
+		var keys []string
+		for key := range entry.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		env := make([]string, len(keys))
+		for i, key := range keys {
+			env[i] = tomlKey(key) + " = " + tomlString(entry.Env[key])
+		}
+		fmt.Fprintf(b, "env = { %s }\n", strings.Join(env, ", "))
+	}
+}
+
+func stringMap(values map[string]interface{}) map[string]string {
+	m := make(map[string]string)
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			m[key] = s
+		}
+	}
+	return m
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tomlDocument is a TOML file split into its tables, keeping the source
+// text so that untouched parts can be written back unchanged
+type tomlDocument struct {
+	text        string
+	preambleEnd int         // end of the key/values before the first table
+	entries     []tomlEntry // the key/values before the first table
+	tables      []tomlTable
+}
+
+// keyedServers returns the MCP servers defined by keys instead of
+// [mcp_servers.<name>] headers: mcp_servers = { ... } or
+// mcp_servers.<name>.command = ... before the first table, and
+// <name> = { ... } or <name>.command = ... in an [mcp_servers] table
+func (doc *tomlDocument) keyedServers() map[string]map[string]interface{} {
+	tree := make(map[string]interface{})
+	for _, entry := range doc.entries {
+		if entry.key[0] == "mcp_servers" {
+			setTOMLValue(tree, entry.key[1:], entry.value)
+		}
+	}
+	for _, table := range doc.tables {
+		if len(table.path) == 1 && table.path[0] == "mcp_servers" {
+			for _, entry := range table.entries {
+				setTOMLValue(tree, entry.key, entry.value)
+			}
+		}
+	}
+
+	servers := make(map[string]map[string]interface{})
+	for name, value := range tree {
+		if values, ok := value.(map[string]interface{}); ok {
+			servers[name] = values
+		}
+	}
+	return servers
+}
+
+// setTOMLValue sets the dotted key in values, creating the tables on the way.
+// An empty key merges value, which must then be a table, into values.
+func setTOMLValue(values map[string]interface{}, key []string, value interface{}) {
+	if len(key) == 0 {
+		if table, ok := value.(map[string]interface{}); ok {
+			for k, v := range table {
+				values[k] = v
+			}
+		}
+		return
+	}
+	if len(key) == 1 {
+		values[key[0]] = value
+		return
+	}
+	table, ok := values[key[0]].(map[string]interface{})
+	if !ok {
+		table = make(map[string]interface{})
+		values[key[0]] = table
+	}
+	setTOMLValue(table, key[1:], value)
+}
+
+func sortedKeys(m map[string]map[string]interface{}) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tomlTable is a [table] or [[array table]] with its key/values
+type tomlTable struct {
+	path       []string
+	start, end int // offsets of the header line and of the next table
+	bodyStart  int // offset after the header line
+	entries    []tomlEntry
+}
+
+// tomlEntry is one key = value line, possibly spanning several lines
+type tomlEntry struct {
+	key        []string
+	value      interface{}
+	start, end int
+}
+
+func (t tomlTable) text(doc string) string   { return doc[t.start:t.end] }
+func (t tomlTable) header(doc string) string { return doc[t.start:t.bodyStart] }
+
+// server returns the name of the MCP server the table belongs to
+func (t tomlTable) server() (string, bool) {
+	if len(t.path) >= 2 && t.path[0] == "mcp_servers" {
+		return t.path[1], true
+	}
+	return "", false
+}
+
+// values returns the table's values, with dotted keys as nested tables
+func (t tomlTable) values() map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, entry := range t.entries {
+		setTOMLValue(values, entry.key, entry.value)
+	}
+	return values
+}
+
+// withoutKeys returns the table body without the entries for keys
+func (t tomlTable) withoutKeys(doc string, keys ...string) string {
+	skip := make(map[string]bool)
+	for _, key := range keys {
+		skip[key] = true
+	}
+	var b strings.Builder
+	pos := t.bodyStart
+	for _, entry := range t.entries {
+		if len(entry.key) >= 1 && skip[entry.key[0]] {
+			b.WriteString(doc[pos:entry.start])
+			pos = entry.end
+		}
+	}
+	b.WriteString(doc[pos:t.end])
+	return b.String()
+}
+
+// parseTOML splits text into tables and parses their values. It reads the
+// subset of TOML that config files use: strings, arrays, inline tables and
+// plain scalars, which are kept as their source text.
+func parseTOML(text string) (*tomlDocument, error) {
+	p := &tomlParser{text: text}
+	doc := &tomlDocument{text: text, preambleEnd: len(text)}
+	var current *tomlTable
+	for {
+		p.skipBlank()
+		if p.pos >= len(text) {
+			break
+		}
+		// skipBlank stops after a newline, so only indentation precedes pos
+		lineStart := strings.LastIndexByte(text[:p.pos], '\n') + 1
+		if text[p.pos] == '[' {
+			array := strings.HasPrefix(text[p.pos:], "[[")
+			p.pos++
+			if array {
+				p.pos++
+			}
+			path, err := p.key()
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			close := "]"
+			if array {
+				close = "]]"
+			}
+			p.skipSpace()
+			if !strings.HasPrefix(text[p.pos:], close) {
+				return nil, p.errorf("expected %s", close)
+			}
+			p.pos += len(close)
+			if err := p.endLine(); err != nil {
+				return nil, err
+			}
+			if current == nil {
+				doc.preambleEnd = lineStart
+			} else {
+				current.end = lineStart
+				doc.tables = append(doc.tables, *current)
+			}
+			current = &tomlTable{path: path, start: lineStart, bodyStart: p.pos}
+			continue
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.skipSpace()
+		if p.pos >= len(text) || text[p.pos] != '=' {
+			return nil, p.errorf("expected = after key")
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.endLine(); err != nil {
+			return nil, err
+		}
+		entry := tomlEntry{key: key, value: value, start: lineStart, end: p.pos}
+		if current != nil {
+			current.entries = append(current.entries, entry)
+		} else {
+			doc.entries = append(doc.entries, entry)
+		}
+	}
+	if current != nil {
+		current.end = len(text)
+		doc.tables = append(doc.tables, *current)
+	}
+	return doc, nil
+}
+
+type tomlParser struct {
+	text string
+	pos  int
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.text[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.text) && (p.text[p.pos] == ' ' || p.text[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			for p.pos < len(p.text) && p.text[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endLine consumes the rest of the line after a header or value, which may
+// only hold a comment
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	if p.pos < len(p.text) && p.text[p.pos] == '#' {
+		for p.pos < len(p.text) && p.text[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.text) && p.text[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.text) {
+		if p.text[p.pos] != '\n' {
+			return p.errorf("unexpected %q", p.text[p.pos])
+		}
+		p.pos++
+	}
+	return nil
+}
+
+// key parses a dotted key
+func (p *tomlParser) key() ([]string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return nil, fmt.Errorf("expected key")
+		}
+		switch p.text[p.pos] {
+		case '"', '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, s)
+		default:
+			start := p.pos
+			for p.pos < len(p.text) && bareTOMLKey.MatchString(p.text[p.pos:p.pos+1]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("expected key")
+			}
+			parts = append(parts, p.text[start:p.pos])
+		}
+		p.skipSpace()
+		if p.pos >= len(p.text) || p.text[p.pos] != '.' {
+			return parts, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) value() (interface{}, error) {
+	if p.pos >= len(p.text) {
+		return nil, p.errorf("expected value")
+	}
+	switch p.text[p.pos] {
+	case '"', '\'':
+		return p.str()
+	case '[':
+		p.pos++
+		var values []interface{}
+		for {
+			p.skipBlank()
+			if p.pos < len(p.text) && p.text[p.pos] == ']' {
+				p.pos++
+				return values, nil
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			p.skipBlank()
+			if p.pos < len(p.text) && p.text[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.text) || p.text[p.pos] != ']' {
+				return nil, p.errorf("expected , or ] in array")
+			}
+		}
+	case '{':
+		p.pos++
+		values := make(map[string]interface{})
+		for {
+			p.skipSpace()
+			if p.pos < len(p.text) && p.text[p.pos] == '}' {
+				p.pos++
+				return values, nil
+			}
+			key, err := p.key()
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			p.skipSpace()
+			if p.pos >= len(p.text) || p.text[p.pos] != '=' {
+				return nil, p.errorf("expected = in inline table")
+			}
+			p.pos++
+			p.skipSpace()
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			setTOMLValue(values, key, value)
+			p.skipSpace()
+			if p.pos < len(p.text) && p.text[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.text) || p.text[p.pos] != '}' {
+				return nil, p.errorf("expected , or } in inline table")
+			}
+		}
+	}
+
+	// Numbers, booleans and dates are kept as written
+	start := p.pos
+	for p.pos < len(p.text) && !strings.ContainsRune(",]}#\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.text[start:p.pos])
+	if raw == "" {
+		return nil, p.errorf("expected value")
+	}
+	return raw, nil
+}
+
+// str parses a basic or literal string, including multi-line ones
+func (p *tomlParser) str() (string, error) {
+	quote := p.text[p.pos]
+	delim := string(quote)
+	if strings.HasPrefix(p.text[p.pos:], strings.Repeat(delim, 3)) {
+		delim = strings.Repeat(delim, 3)
+	}
+	p.pos += len(delim)
+	if len(delim) == 3 {
+		// A newline right after the opening delimiter is not part of the string
+		if strings.HasPrefix(p.text[p.pos:], "\r\n") {
+			p.pos += 2
+		} else if strings.HasPrefix(p.text[p.pos:], "\n") {
+			p.pos++
+		}
+	}
+
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.text) || (len(delim) == 1 && p.text[p.pos] == '\n') {
+			return "", p.errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.text[p.pos:], delim) {
+			p.pos += len(delim)
+			return b.String(), nil
+		}
+		c := p.text[p.pos]
+		if c != '\\' || quote == '\'' {
+			r, size := utf8.DecodeRuneInString(p.text[p.pos:])
+			b.WriteRune(r)
+			p.pos += size
+			continue
+		}
+
+		p.pos++
+		if p.pos >= len(p.text) {
+			return "", p.errorf("unterminated string")
+		}
+		esc := p.text[p.pos]
+		p.pos++
+		switch esc {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(esc)
+		case 'u', 'U':
+			n := 4
+			if esc == 'U' {
+				n = 8
+			}
+			if p.pos+n > len(p.text) {
+				return "", p.errorf("invalid escape")
+			}
+			code, err := strconv.ParseUint(p.text[p.pos:p.pos+n], 16, 32)
+			if err != nil {
+				return "", p.errorf("invalid escape")
+			}
+			b.WriteRune(rune(code))
+			p.pos += n
+		default:
+			if len(delim) == 3 && (esc == '\n' || esc == ' ' || esc == '\t' || esc == '\r') {
+				// Line ending backslash: trim the following whitespace
+				p.pos--
+				for p.pos < len(p.text) && strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])) {
+					p.pos++
+				}
+				continue
+			}
+			return "", p.errorf("invalid escape \\%c", esc)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeCodexConfig(t *testing.T, text string) codexTarget {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir()) // backups go under the home directory
+	target := codexTarget{path: filepath.Join(t.TempDir(), "config.toml")}
+	if err := os.WriteFile(target.path, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func readCodexConfig(t *testing.T, target codexTarget) string {
+	t.Helper()
+	data, err := os.ReadFile(target.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+const codexConfig = `# Codex settings
+model = "o3" # the default model
+approval_policy = "on-request"
+
+[profiles.fast]
+model = "gpt-4.1"
+
+# Servers
+[mcp_servers.docs]
+command = "npx"
+args = [
+  "-y", # the package is fetched on first use
+  "docs-mcp",
+]
+startup_timeout_ms = 20_000 # kept as written
+
+[mcp_servers.docs.env]
+DOCS_TOKEN = 'lit\eral'
+
+[mcp_servers.notes]
+command = """
+notes-mcp"""
+args = ["--dir", "~/notes"]
+
+[tui]
+notifications = true
+`
+
+func TestCodexRead(t *testing.T) {
+	target := writeCodexConfig(t, codexConfig)
+	servers, err := target.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]MCPServerEntry{
+		"docs":  {Command: "npx", Args: []string{"-y", "docs-mcp"}, Env: map[string]string{"DOCS_TOKEN": `lit\eral`}},
+		"notes": {Command: "notes-mcp", Args: []string{"--dir", "~/notes"}},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("Read() = %#v, want %#v", servers, want)
+	}
+}
+
+func TestCodexWriteKeepsTheRest(t *testing.T) {
+	target := writeCodexConfig(t, codexConfig)
+	err := target.Write(map[string]MCPServerEntry{
+		"docs": {Command: "docs-mcp", Env: map[string]string{"DOCS_TOKEN": "new"}},
+		"web":  {Command: "uvx", Args: []string{"web-mcp", `C:\path "quoted"`}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Codex settings
+model = "o3" # the default model
+approval_policy = "on-request"
+
+[profiles.fast]
+model = "gpt-4.1"
+
+# Servers
+[mcp_servers.docs]
+command = "docs-mcp"
+env = { DOCS_TOKEN = "new" }
+startup_timeout_ms = 20_000 # kept as written
+
+[mcp_servers.notes]
+command = """
+notes-mcp"""
+args = ["--dir", "~/notes"]
+
+[tui]
+notifications = true
+
+[mcp_servers.web]
+command = "uvx"
+args = ["web-mcp", "C:\\path \"quoted\""]
+`
+	if got := readCodexConfig(t, target); got != want {
+		t.Errorf("config after Write:\n%s\nwant:\n%s", got, want)
+	}
+
+	servers, err := target.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := servers["web"].Args[1]; got != `C:\path "quoted"` {
+		t.Errorf("web arg read back as %q", got)
+	}
+}
+
+func TestCodexRemove(t *testing.T) {
+	target := writeCodexConfig(t, codexConfig)
+	if err := target.Write(nil, []string{"docs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readCodexConfig(t, target)
+	if strings.Contains(got, "docs") || strings.Contains(got, "DOCS_TOKEN") {
+		t.Errorf("docs server not fully removed:\n%s", got)
+	}
+	for _, kept := range []string{"# Codex settings", "[profiles.fast]", "[mcp_servers.notes]", "notifications = true"} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("%q was lost:\n%s", kept, got)
+		}
+	}
+	servers, err := target.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := servers["docs"]; ok || len(servers) != 1 {
+		t.Errorf("servers after remove: %v", servers)
+	}
+}
+
+func TestCodexKeyedServers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"inline table", `[mcp_servers]
+docs = { command = "npx", args = ["-y", "docs-mcp"], env = { DOCS_TOKEN = "x" } }
+`},
+		{"dotted keys in mcp_servers", `[mcp_servers]
+docs.command = "npx"
+docs.args = ["-y", "docs-mcp"]
+docs.env.DOCS_TOKEN = "x"
+`},
+		{"top-level dotted keys", `model = "o3"
+mcp_servers.docs.command = "npx"
+mcp_servers.docs.args = ["-y", "docs-mcp"]
+mcp_servers.docs.env = { DOCS_TOKEN = "x" }
+`},
+		{"top-level inline table", `mcp_servers = { docs = { command = "npx", args = ["-y", "docs-mcp"], env.DOCS_TOKEN = "x" } }
+`},
+	}
+	want := MCPServerEntry{Command: "npx", Args: []string{"-y", "docs-mcp"}, Env: map[string]string{"DOCS_TOKEN": "x"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := writeCodexConfig(t, tt.text)
+			servers, err := target.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(servers["docs"], want) {
+				t.Errorf("docs = %#v, want %#v", servers["docs"], want)
+			}
+
+			err = target.Write(map[string]MCPServerEntry{"docs": {Command: "docs-mcp"}}, nil)
+			if err == nil || !strings.Contains(err.Error(), "docs") {
+				t.Errorf("Write returned %v, want a refusal naming docs", err)
+			}
+			if got := readCodexConfig(t, target); got != tt.text {
+				t.Errorf("refused Write changed the file:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestCodexDottedEnvInServerTable(t *testing.T) {
+	target := writeCodexConfig(t, `[mcp_servers.docs]
+command = "npx"
+env.DOCS_TOKEN = "x"
+`)
+	servers, err := target.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servers["docs"].Env["DOCS_TOKEN"] != "x" {
+		t.Errorf("docs = %#v", servers["docs"])
+	}
+
+	if err := target.Write(map[string]MCPServerEntry{"docs": {Command: "npx", Env: map[string]string{"DOCS_TOKEN": "y"}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `[mcp_servers.docs]
+command = "npx"
+env = { DOCS_TOKEN = "y" }
+`
+	if got := readCodexConfig(t, target); got != want {
+		t.Errorf("config after Write:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCodexNewFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	target := codexTarget{path: filepath.Join(t.TempDir(), "codex", "config.toml")}
+	if _, err := target.Read(); !os.IsNotExist(err) {
+		t.Fatalf("Read of a missing file returned %v", err)
+	}
+	if err := target.Write(map[string]MCPServerEntry{"my server": {Command: "srv"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "[mcp_servers.\"my server\"]\ncommand = \"srv\"\n"
+	if got := readCodexConfig(t, target); got != want {
+		t.Errorf("new config:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCodexRefusesInvalidTOML(t *testing.T) {
+	text := "model = \"o3\n[mcp_servers.docs]\ncommand = \"npx\"\n"
+	target := writeCodexConfig(t, text)
+	if _, err := target.Read(); err == nil {
+		t.Error("Read accepted an unterminated string")
+	}
+	if err := target.Write(map[string]MCPServerEntry{"web": {Command: "uvx"}}, nil); err == nil {
+		t.Error("Write accepted an unterminated string")
+	}
+	if got := readCodexConfig(t, target); got != text {
+		t.Errorf("refused Write changed the file:\n%s", got)
+	}
+}
@@ -1,52 +1,357 @@
 package src
 
 import (
+	"context"
+	_ "embed"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
-func loadAITools() []AITool {
-	// Load tools from ai_tools.json in the project directory
-	data, err := os.ReadFile("ai_tools.json")
+// builtinCatalog is the tool catalog shipped with the binary
+//
+//go:embed ai_tools.json
+var builtinCatalog []byte
+
+// Catalog layers, from lowest to highest precedence
+const (
+	sourceBuiltin = "builtin"
+	sourceUser    = "user"
+	sourceProject = "project"
+)
+
+type catalogLayer struct {
+	source string
+	path   string
+	data   []byte
+}
+
+// defaultParallelism is the number of concurrent installs when not configured
+const defaultParallelism = 4
+
+// defaultInstallTimeout bounds a single tool's install when not configured
+const defaultInstallTimeout = 30 * time.Minute
+
+// appConfig holds the settings in ~/.ai-cli-manager/config.json
+type appConfig struct {
+	GitHubUser     string              `json:"github_user"`
+	GitHubRepo     string              `json:"github_repo"`
+	Parallelism    int                 `json:"parallelism,omitempty"`     // concurrent installs
+	InstallTimeout string              `json:"install_timeout,omitempty"` // per tool, e.g. "10m"; "0" disables it
+	TotalTimeout   string              `json:"total_timeout,omitempty"`   // for a whole install run; unset means none
+	ManagedPrefix  bool                `json:"managed_prefix,omitempty"`  // install npm, pip, go and binary tools under ~/.ai-cli-manager/tools
+	MCPConfigPath  string              `json:"mcp_config_path,omitempty"` // MCP config file to edit instead of Claude Desktop's
+	MCPClients     map[string][]string `json:"mcp_clients,omitempty"`     // client IDs per MCP server key, see mcpTargets
+}
+
+// parseTimeout parses a duration setting, falling back to def when it is
+// unset or invalid. Zero means no timeout.
+func parseTimeout(value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return def
+	}
+	return d
+}
+
+// toolTimeout is how long one install of tool may take
+func (c appConfig) toolTimeout(tool AITool) time.Duration {
+	return parseTimeout(tool.Timeout, parseTimeout(c.InstallTimeout, defaultInstallTimeout))
+}
+
+// withTimeout bounds ctx by d unless d is zero
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func appConfigPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "config.json")
+}
+
+func loadAppConfig() appConfig {
+	var config appConfig
+	if data, err := os.ReadFile(appConfigPath()); err == nil {
+		json.Unmarshal(data, &config)
+	}
+	if config.Parallelism <= 0 {
+		config.Parallelism = defaultParallelism
+	}
+	return config
+}
+
+func saveAppConfig(config appConfig) error {
+	configPath := appConfigPath()
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0644)
+}
+
+func userToolsPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "tools.json")
+}
+
+// upstreamSnapshotPath holds the builtin catalog as of the last merge
+func upstreamSnapshotPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "upstream.json")
+}
+
+// projectToolsPath returns the project-level catalog, looked up as
+// ai_tools.json in the current directory or its parent.
+func projectToolsPath() string {
+	for _, path := range []string{"ai_tools.json", filepath.Join("..", "ai_tools.json")} {
+		if _, err := os.Stat(path); err == nil {
+			if abs, err := filepath.Abs(path); err == nil {
+				return abs
+			}
+			return path
+		}
+	}
+	return ""
+}
+
+// catalogLayers returns every catalog layer that exists, lowest precedence
+// first, with a warning for a user file that is ignored
+func catalogLayers() ([]catalogLayer, []validationIssue) {
+	layers := []catalogLayer{{source: sourceBuiltin, path: "(embedded)", data: builtinCatalog}}
+	var issues []validationIssue
+
+	if data, err := os.ReadFile(userToolsPath()); err == nil {
+		if isLegacyCatalogCopy(data) {
+			issues = append(issues, validationIssue{
+				File:    userToolsPath(),
+				Line:    1,
+				Column:  1,
+				Message: "ignored: this is a full copy of the catalog saved by an older version, which would hide every update to the shipped tools; keep only your own tools and changes in it, or delete it",
+			})
+		} else {
+			data = migrateCatalogFile(userToolsPath(), data)
+			layers = append(layers, catalogLayer{source: sourceUser, path: userToolsPath(), data: data})
+		}
+	}
+
+	if path := projectToolsPath(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			data = migrateCatalogFile(path, data)
+			layers = append(layers, catalogLayer{source: sourceProject, path: path, data: data})
+		}
+	}
+
+	return layers, issues
+}
+
+// isLegacyCatalogCopy reports whether user catalog data is a full copy of
+// the shipped catalog, as older versions saved it: a file that defines every
+// builtin tool and has no schema_version, or has one only because it was
+// migrated from such a file.
+func isLegacyCatalogCopy(data []byte) bool {
+	version, err := catalogSchemaVersion(data)
 	if err != nil {
-		// Fallback: try relative path
-		data, err = os.ReadFile("../ai_tools.json")
-		if err != nil {
-			// Last fallback: try in current working directory
-			if wd, err := os.Getwd(); err == nil {
-				data, err = os.ReadFile(filepath.Join(wd, "ai_tools.json"))
+		return false
+	}
+	if version != 1 {
+		migrated, _ := filepath.Glob(userToolsPath() + ".v1.*.bak")
+		if len(migrated) == 0 {
+			return false
+		}
+	}
+	entries, err := parseCatalogEntries(data)
+	if err != nil {
+		return false
+	}
+	builtin, err := parseCatalogEntries(builtinCatalog)
+	if err != nil || len(builtin) == 0 {
+		return false
+	}
+
+	names := make(map[string]bool)
+	for _, fields := range entries {
+		names[entryName(fields)] = true
+	}
+	for _, fields := range builtin {
+		if !names[entryName(fields)] {
+			return false
+		}
+	}
+	return true
+}
+
+// catalogEntry holds the raw fields of a tool while layers are merged, so
+// that a layer only overrides the fields it actually sets.
+type catalogEntry struct {
+	fields map[string]json.RawMessage
+	source string
+	layer  catalogLayer // the highest layer that defines the tool
+	off    int          // where that layer defines it
+	setIn  map[string]catalogLayer
+	setAt  map[string]int // where the layer in setIn sets each field
+}
+
+// locate reports where key was last set, or where the tool was last
+// defined when key is "" or not set
+func (e *catalogEntry) locate(key string) (string, []byte, int) {
+	if layer, ok := e.setIn[key]; ok {
+		return layer.path, layer.data, e.setAt[key]
+	}
+	return e.layer.path, e.layer.data, e.off
+}
+
+// set records a layer's fields in the entry
+func (e *catalogEntry) set(layer catalogLayer, scanned scannedEntry) {
+	for key, value := range scanned.fields {
+		for _, linked := range linkedFields[key] {
+			if _, ok := scanned.fields[linked]; !ok {
+				delete(e.fields, linked)
+				delete(e.setIn, linked)
 			}
 		}
+		e.fields[key] = value
+		e.setIn[key] = layer
+		e.setAt[key] = scanned.keyOff[key]
 	}
+	e.source = layer.source
+	e.layer = layer
+	e.off = scanned.off
+}
+
+// linkedFields are replaced together when a higher layer sets any of them,
+// so that overriding install_cmd also drops the lower layer's methods.
+var linkedFields = map[string][]string{
+	"install_cmd":     {"install_methods"},
+	"install_methods": {"install_cmd"},
+}
 
+func parseCatalogEntries(data []byte) ([]map[string]json.RawMessage, error) {
+	migrated, _, err := migrateCatalog(data)
 	if err != nil {
-		// Return empty slice if no JSON file found
-		return []AITool{}
+		return nil, err
+	}
+	var doc struct {
+		Tools []map[string]json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal(migrated, &doc); err != nil {
+		return nil, err
 	}
+	return doc.Tools, nil
+}
+
+func entryName(fields map[string]json.RawMessage) string {
+	var name string
+	json.Unmarshal(fields["name"], &name)
+	return name
+}
 
-	var tools []AITool
-	if err := json.Unmarshal(data, &tools); err != nil {
-		// Return empty slice if JSON is invalid
-		return []AITool{}
+// mergeLayers merges catalog layers, lowest precedence first. Entries are
+// matched by name: a new name adds a tool and an existing name overrides
+// only the fields present in the higher layer. It returns the tools in the
+// order they were first defined, with the problems found in each layer.
+func mergeLayers(layers []catalogLayer) ([]*catalogEntry, []validationIssue) {
+	var entries []*catalogEntry
+	var issues []validationIssue
+	index := make(map[string]*catalogEntry)
+
+	for _, layer := range layers {
+		layerEntries, layerIssues := scanLayer(layer.path, layer.data)
+		issues = append(issues, layerIssues...)
+
+		for _, scanned := range layerEntries {
+			name := entryName(scanned.fields)
+			entry, ok := index[name]
+			if !ok {
+				entry = &catalogEntry{
+					fields: make(map[string]json.RawMessage),
+					setIn:  make(map[string]catalogLayer),
+					setAt:  make(map[string]int),
+				}
+				index[name] = entry
+				entries = append(entries, entry)
+			}
+			entry.set(layer, scanned)
+		}
+	}
+
+	return entries, issues
+}
+
+// loadCatalog merges every catalog layer into the final tool list and
+// returns the problems found along the way. "disabled": true hides a tool
+// entirely, and tools that are still missing required fields after merging
+// are dropped.
+func loadCatalog() ([]AITool, []validationIssue) {
+	layers, issues := catalogLayers()
+	entries, layerIssues := mergeLayers(layers)
+	issues = append(issues, layerIssues...)
+
+	tools := make([]AITool, 0, len(entries))
+	for _, entry := range entries {
+		tool, err := entryTool(entry.fields)
+		if err != nil || tool.Disabled {
+			continue
+		}
+		if required := requiredIssues(entry.fields, entry.locate); len(required) > 0 {
+			issues = append(issues, required...)
+			continue
+		}
+		tool.Source = entry.source
+		tools = append(tools, tool)
 	}
 
-	// Also save to user config for backup
-	saveAITools(tools)
+	return tools, issues
+}
+
+// overrideBase returns the layers below file when it is the user or the
+// project catalog, which only override the tools beneath them
+func overrideBase(file string) ([]catalogLayer, bool) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, false
+	}
+	layers, _ := catalogLayers()
+	for i, layer := range layers {
+		if layer.source == sourceBuiltin {
+			continue
+		}
+		if layerInfo, err := os.Stat(layer.path); err == nil && os.SameFile(info, layerInfo) {
+			return layers[:i], true
+		}
+	}
+	if userInfo, err := os.Stat(userToolsPath()); err == nil && os.SameFile(info, userInfo) {
+		// An ignored legacy copy still overrides the builtin catalog
+		return layers[:1], true
+	}
+	return nil, false
+}
+
+func loadAITools() []AITool {
+	tools, _ := loadCatalog()
 	return tools
 }
 
 func saveAITools(tools []AITool) error {
-	homeDir, _ := os.UserHomeDir()
-	configDir := filepath.Join(homeDir, ".ai-cli-manager")
-	configPath := filepath.Join(configDir, "tools.json")
+	configPath := userToolsPath()
 
 	// Create directory if it doesn't exist
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(tools, "", "  ")
+	data, err := encodeCatalog(tools)
 	if err != nil {
 		return err
 	}
@@ -56,24 +361,20 @@ func saveAITools(tools []AITool) error {
 
 // ExportToolsConfig exports the current tools configuration to a JSON file
 func ExportToolsConfig(tools []AITool, filename string) error {
-	data, err := json.MarshalIndent(tools, "", "  ")
+	data, err := encodeCatalog(tools)
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(filename, data, 0644)
 }
 
-// ImportToolsConfig imports tools configuration from a JSON file
+// ImportToolsConfig imports tools configuration from a JSON file, upgrading
+// older catalog formats as needed
 func ImportToolsConfig(filename string) ([]AITool, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	var tools []AITool
-	if err := json.Unmarshal(data, &tools); err != nil {
-		return nil, err
-	}
-
-	return tools, nil
-}
\ No newline at end of file
+	return decodeCatalog(data)
+}
@@ -0,0 +1,136 @@
+package src
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// catalogHome points the user layer at a temporary home and leaves the
+// project layer out
+func catalogHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.MkdirAll(filepath.Join(home, ".ai-cli-manager"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func builtinNames(t *testing.T) []string {
+	t.Helper()
+	entries, err := parseCatalogEntries(builtinCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fields := range entries {
+		names = append(names, entryName(fields))
+	}
+	return names
+}
+
+func TestLoadCatalogIgnoresLegacyCopy(t *testing.T) {
+	catalogHome(t)
+
+	// An unversioned full copy, with one tool changed the way an old
+	// version would have frozen it
+	var legacy []map[string]interface{}
+	for _, name := range builtinNames(t) {
+		legacy = append(legacy, map[string]interface{}{
+			"name":        name,
+			"cli_command": "frozen",
+			"install_cmd": "npm install -g frozen",
+		})
+	}
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userToolsPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, issues := loadCatalog()
+	if len(issues) != 1 || issues[0].File != userToolsPath() || !strings.Contains(issues[0].Message, "ignored") {
+		t.Fatalf("issues = %v, want one warning about %s", issues, userToolsPath())
+	}
+	for _, tool := range tools {
+		if tool.CLICommand == "frozen" || tool.Source != sourceBuiltin {
+			t.Errorf("%s came from the legacy copy", tool.Name)
+		}
+	}
+	if after, _ := os.ReadFile(userToolsPath()); string(after) != string(data) {
+		t.Error("the legacy copy was rewritten")
+	}
+}
+
+func TestLoadCatalogKeepsPartialUserFile(t *testing.T) {
+	catalogHome(t)
+
+	// Without a schema_version, but only overriding some tools
+	names := builtinNames(t)
+	data := []byte(`[{"name": "` + names[0] + `", "cli_command": "mine"}]`)
+	if err := os.WriteFile(userToolsPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, issues := loadCatalog()
+	if len(issues) != 0 {
+		t.Fatalf("issues = %v", issues)
+	}
+	for _, tool := range tools {
+		if tool.Name == names[0] && (tool.CLICommand != "mine" || tool.Source != sourceUser) {
+			t.Errorf("%s = %q from %s, want the user override", tool.Name, tool.CLICommand, tool.Source)
+		}
+	}
+}
+
+func TestLoadCatalogReportsOverridingLayer(t *testing.T) {
+	catalogHome(t)
+
+	names := builtinNames(t)
+	data := []byte(`{
+  "schema_version": 3,
+  "tools": [
+    {
+      "name": "` + names[0] + `",
+      "cli_command": ""
+    },
+    {
+      "name": "` + names[1] + `",
+      "install_methods": [],
+      "github_repo": ""
+    }
+  ]
+}
+`)
+	if err := os.WriteFile(userToolsPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, issues := loadCatalog()
+	want := map[string]string{
+		"cli_command":     userToolsPath() + ":6:7",
+		"install_methods": userToolsPath() + ":10:7",
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v", issues)
+	}
+	for _, issue := range issues {
+		if got := issue.String(); !strings.HasPrefix(got, want[issue.Field]+":") {
+			t.Errorf("issue = %s, want it at %s", got, want[issue.Field])
+		}
+	}
+}
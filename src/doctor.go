@@ -0,0 +1,122 @@
+package src
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// doctorCheck is one finding of the doctor command
+type doctorCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// pathHint tells the user how to put dir on PATH
+func pathHint(dir string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf(`run: setx PATH "%%PATH%%;%s" and open a new terminal`, dir)
+	}
+	return fmt.Sprintf(`add to your shell profile (~/.bashrc, ~/.zshrc, ...): export PATH="%s:$PATH"`, dir)
+}
+
+// runDoctorChecks checks that the managed bin directory is on PATH and that
+// the command of every tool installed there resolves to its shim
+func runDoctorChecks(tools []AITool) []doctorCheck {
+	binDir := managedBinDir()
+	checks := []doctorCheck{{Name: "PATH", OK: true, Message: binDir + " is on PATH"}}
+	if !onPath(binDir) {
+		checks[0] = doctorCheck{Name: "PATH", Message: binDir + " is not on PATH", Hint: pathHint(binDir)}
+	}
+
+	state := loadInstallState()
+	var names []string
+	for name := range state.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		record := state.Tools[name]
+		i, ok := findTool(tools, name)
+		if !ok || (record.Prefix == "" && record.Method != "binary") {
+			continue
+		}
+		tool := tools[i]
+
+		entry := filepath.Join(binDir, tool.CLICommand)
+		if record.Prefix != "" {
+			entry = shimPath(tool.CLICommand)
+		}
+		check := doctorCheck{Name: tool.Name}
+		if _, err := os.Stat(entry); err != nil {
+			check.Message = fmt.Sprintf("%s is missing or broken", entry)
+			check.Hint = fmt.Sprintf("reinstall %s", tool.Name)
+			if target, err := shimTarget(entry); err == nil {
+				check.Message = fmt.Sprintf("%s points to %s, which does not exist", entry, target)
+			}
+		} else if resolved, err := exec.LookPath(tool.CLICommand); err == nil && !sameFile(resolved, entry) {
+			check.Message = fmt.Sprintf("%s runs %s instead of %s", tool.CLICommand, resolved, entry)
+			check.Hint = fmt.Sprintf("move %s before %s on PATH", binDir, filepath.Dir(resolved))
+		} else {
+			check.OK = true
+			check.Message = entry
+			if target, err := shimTarget(entry); err == nil {
+				check.Message += " -> " + target
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func sameFile(a, b string) bool {
+	ia, errA := os.Lstat(a)
+	ib, errB := os.Lstat(b)
+	return errA == nil && errB == nil && os.SameFile(ia, ib)
+}
+
+func writeDoctorChecks(w io.Writer, checks []doctorCheck) {
+	for _, check := range checks {
+		mark := "✓"
+		if !check.OK {
+			mark = "✗"
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", mark, check.Name, check.Message)
+		if check.Hint != "" {
+			fmt.Fprintf(w, "  %s\n", check.Hint)
+		}
+	}
+}
+
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	if _, err := parseFlags(fs, args); err != nil {
+		return 2
+	}
+
+	checks := runDoctorChecks(loadAITools())
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(checks)
+	} else {
+		writeDoctorChecks(os.Stdout, checks)
+	}
+
+	for _, check := range checks {
+		if !check.OK {
+			return 1
+		}
+	}
+	return 0
+}
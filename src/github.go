@@ -1,8 +1,9 @@
 package src
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,48 +13,16 @@ import (
 )
 
 func (m *Model) loadGitHubConfig() {
-	homeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(homeDir, ".ai-cli-manager", "config.json")
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return
-	}
-
-	var config struct {
-		GitHubUser string `json:"github_user"`
-		GitHubRepo string `json:"github_repo"`
-	}
-
-	if json.Unmarshal(data, &config) == nil {
-		m.githubUser = config.GitHubUser
-		m.githubRepo = config.GitHubRepo
-	}
+	config := loadAppConfig()
+	m.githubUser = config.GitHubUser
+	m.githubRepo = config.GitHubRepo
 }
 
 func (m *Model) saveGitHubConfig() error {
-	homeDir, _ := os.UserHomeDir()
-	configDir := filepath.Join(homeDir, ".ai-cli-manager")
-	configPath := filepath.Join(configDir, "config.json")
-
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return err
-	}
-
-	config := struct {
-		GitHubUser string `json:"github_user"`
-		GitHubRepo string `json:"github_repo"`
-	}{
-		GitHubUser: m.githubUser,
-		GitHubRepo: m.githubRepo,
-	}
-
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(configPath, data, 0644)
+	config := loadAppConfig()
+	config.GitHubUser = m.githubUser
+	config.GitHubRepo = m.githubRepo
+	return saveAppConfig(config)
 }
 
 func (m Model) checkGitHubCLI() tea.Cmd {
@@ -78,7 +47,7 @@ func (m Model) syncWithGitHub() tea.Cmd {
 			}
 		}
 
-		data, err := json.MarshalIndent(m.tools, "", "  ")
+		data, err := encodeCatalog(m.tools)
 		if err != nil {
 			return githubSyncMsg{success: false, err: err}
 		}
@@ -133,8 +102,8 @@ func (m *Model) pullFromGitHub() tea.Cmd {
 			return githubSyncMsg{success: false, err: err}
 		}
 
-		var tools []AITool
-		if err := json.Unmarshal(output, &tools); err != nil {
+		tools, err := decodeCatalog(output)
+		if err != nil {
 			return githubSyncMsg{success: false, err: err}
 		}
 
@@ -145,53 +114,138 @@ func (m *Model) pullFromGitHub() tea.Cmd {
 	}
 }
 
-func (m Model) installFromGitHub(tool AITool) error {
+// githubInstallers are tried in order on a cloned repository; the first
+// whose file exists is run in the clone.
+var githubInstallers = []struct {
+	file string
+	argv []string
+}{
+	{"install.sh", []string{"sh", "install.sh"}},
+	{"scripts/install.sh", []string{"sh", "scripts/install.sh"}},
+	{"setup.sh", []string{"sh", "setup.sh"}},
+	{"package.json", []string{"npm", "install", "-g", "."}},
+	{"setup.py", []string{"pip", "install", "."}},
+	{"go.mod", []string{"go", "install", "."}},
+}
+
+// githubCloneDir is where a tool's repository is cloned for installing
+func githubCloneDir(tool AITool) string {
+	return filepath.Join("/tmp", "ai-cli-install", tool.Name)
+}
+
+// installFromGitHub clones the tool's repository and runs its installer,
+// returning the commit it was installed from
+func installFromGitHub(ctx context.Context, tool AITool, out io.Writer, review scriptReviewer) (string, error) {
 	if tool.GitHubRepo == "" {
-		return fmt.Errorf("no GitHub repository specified")
+		return "", fmt.Errorf("no GitHub repository specified")
 	}
 
-	tempDir := filepath.Join("/tmp", "ai-cli-install", tool.Name)
+	tempDir := githubCloneDir(tool)
 	os.RemoveAll(tempDir)
 	defer os.RemoveAll(tempDir)
 
-	cmd := exec.Command("git", "clone", tool.GitHubRepo, tempDir)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to clone repository: %w", err)
+	if err := runInDir(ctx, out, "", "git", "clone", tool.GitHubRepo, tempDir); err != nil {
+		return "", fmt.Errorf("failed to clone repository: %w", err)
+	}
+	if err := checkoutPin(ctx, tool, tempDir, out); err != nil {
+		return "", err
 	}
 
-	installScripts := []string{
-		filepath.Join(tempDir, "install.sh"),
-		filepath.Join(tempDir, "scripts/install.sh"),
-		filepath.Join(tempDir, "setup.sh"),
+	commit, err := verifyCheckout(tool, tempDir)
+	if err != nil {
+		return "", err
+	}
+	if commit == "" {
+		fmt.Fprintf(out, "Warning: %s is not pinned to a commit; set github_commit in the catalog\n", tool.Name)
 	}
+	head, err := gitHead(tempDir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, installer := range githubInstallers {
+		path := filepath.Join(tempDir, installer.file)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
 
-	for _, script := range installScripts {
-		if _, err := os.Stat(script); err == nil {
-			cmd := exec.Command("sh", script)
-			cmd.Dir = tempDir
-			return cmd.Run()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if err := approveScript(ctx, scriptReview{
+			tool:     tool,
+			source:   tool.GitHubRepo,
+			file:     installer.file,
+			command:  strings.Join(installer.argv, " "),
+			commit:   commit,
+			content:  content,
+			sha256:   sha256Hex(content),
+			approved: tool.ScriptSHA256,
+			field:    "script_sha256",
+		}, review, out); err != nil {
+			return "", err
 		}
+		return head, runInDir(ctx, out, tempDir, installer.argv[0], installer.argv[1:]...)
 	}
 
-	if _, err := os.Stat(filepath.Join(tempDir, "package.json")); err == nil {
-		cmd := exec.Command("npm", "install", "-g", ".")
-		cmd.Dir = tempDir
-		return cmd.Run()
+	return "", fmt.Errorf("no installation method found")
+}
+
+// checkoutPin checks out github_ref, or else github_commit, in the clone in
+// dir. A commit that the clone did not bring along is fetched first.
+func checkoutPin(ctx context.Context, tool AITool, dir string, out io.Writer) error {
+	ref := tool.GitHubRef
+	if ref == "" {
+		ref = tool.GitHubCommit
+	}
+	if ref == "" {
+		return nil
+	}
+	if err := runInDir(ctx, out, dir, "git", "checkout", "--detach", ref); err == nil {
+		return nil
+	} else if ref != tool.GitHubCommit {
+		return fmt.Errorf("failed to check out %s: %w", ref, err)
 	}
 
-	if _, err := os.Stat(filepath.Join(tempDir, "setup.py")); err == nil {
-		cmd := exec.Command("pip", "install", ".")
-		cmd.Dir = tempDir
-		return cmd.Run()
+	if err := runInDir(ctx, out, dir, "git", "fetch", "origin", ref); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", ref, err)
 	}
+	if err := runInDir(ctx, out, dir, "git", "checkout", "--detach", ref); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", ref, err)
+	}
+	return nil
+}
 
-	if _, err := os.Stat(filepath.Join(tempDir, "go.mod")); err == nil {
-		cmd := exec.Command("go", "install", ".")
-		cmd.Dir = tempDir
-		return cmd.Run()
+// verifyCheckout checks that the clone in dir is at the commit the catalog
+// pins, and returns the checked out commit if the tool is pinned.
+func verifyCheckout(tool AITool, dir string) (string, error) {
+	expected := strings.ToLower(tool.GitHubCommit)
+	if expected == "" && commitPattern.MatchString(tool.GitHubRef) {
+		// A ref that is itself a (possibly abbreviated) SHA pins the commit
+		expected = strings.ToLower(tool.GitHubRef)
+	}
+	if expected == "" {
+		return "", nil
 	}
 
-	return fmt.Errorf("no installation method found")
+	head, err := gitHead(dir)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(head, expected) {
+		return "", fmt.Errorf("checked out commit %s does not match the pinned %s", head, expected)
+	}
+	return head, nil
+}
+
+// gitHead returns the commit checked out in dir
+func gitHead(dir string) (string, error) {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot read the checked out commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 func (m Model) viewConfig() string {
@@ -223,4 +277,4 @@ Example: username/ai-cli-config
 		selectedStyle.Render("→"),
 		m.message,
 	)
-}
\ No newline at end of file
+}
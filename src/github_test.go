@@ -0,0 +1,109 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository whose install.sh writes its version to
+// marker, with one commit per version, and returns the commit SHAs
+func gitRepo(t *testing.T, marker string, versions ...string) (string, []string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	git("init", "-q")
+	var commits []string
+	for _, version := range versions {
+		script := "echo " + version + " > " + marker + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "install.sh"), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "install.sh")
+		git("commit", "-q", "-m", version)
+		commits = append(commits, git("rev-parse", "HEAD"))
+	}
+	return dir, commits
+}
+
+func approveAll(context.Context, scriptReview) error { return nil }
+
+func TestInstallFromGitHubCommitOnly(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "installed")
+	repo, commits := gitRepo(t, marker, "v1", "v2")
+
+	// Upstream has moved past the pinned commit
+	tool := AITool{Name: "pinned-test", GitHubRepo: repo, GitHubCommit: commits[0]}
+	commit, err := installFromGitHub(context.Background(), tool, io.Discard, approveAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit != commits[0] {
+		t.Errorf("installed from %s, want %s", commit, commits[0])
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "v1" {
+		t.Errorf("installed %q, want the pinned v1", data)
+	}
+}
+
+func TestInstallFromGitHubPinMismatch(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "installed")
+	repo, commits := gitRepo(t, marker, "v1", "v2")
+
+	tool := AITool{Name: "mismatch-test", GitHubRepo: repo, GitHubRef: commits[1], GitHubCommit: commits[0]}
+	_, err := installFromGitHub(context.Background(), tool, io.Discard, approveAll)
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("got error %v, want a commit mismatch", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("the installer ran despite the mismatch")
+	}
+}
+
+func TestRunScriptNeedsApproval(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	script := []byte("touch " + marker + "\n")
+	srv := serveFiles(t, map[string][]byte{"/install.sh": script})
+	tool := AITool{Name: "Script"}
+	method := InstallMethod{Type: "script", URL: srv.URL + "/install.sh"}
+
+	err := runScript(context.Background(), tool, method, io.Discard, nil)
+	if !errors.Is(err, errScriptNotApproved) {
+		t.Fatalf("unreviewed script: got %v, want errScriptNotApproved", err)
+	}
+
+	method.SHA256 = strings.Repeat("0", 64)
+	if err := runScript(context.Background(), tool, method, io.Discard, approveAll); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("mismatching script: got %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("an unapproved script ran")
+	}
+
+	method.SHA256 = sha256Hex(script)
+	if err := runScript(context.Background(), tool, method, io.Discard, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("pre-approved script did not run: %v", err)
+	}
+}
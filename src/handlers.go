@@ -1,8 +1,13 @@
 package src
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,18 +18,19 @@ import (
 func (m Model) handleMenuInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "ctrl+c":
-		return m, tea.Quit
+		return m.quit()
 	case "1", "esc":
 		m.mode = "table"
 		m.updateTable()
 		return m, nil
 	case "2":
-		return m, m.installAll()
+		return m.startInstallAll()
 	case "3":
 		m.mode = "config"
 		return m, nil
 	case "4":
 		m.mode = "mcp"
+		m.mcpStates = readMCPTargets(m.mcpTargets())
 		return m, nil
 	case "5":
 		m.mode = "table"
@@ -41,21 +47,67 @@ func (m Model) handleTableInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.mode = "menu"
 		return m, nil
 	case "ctrl+c":
-		return m, tea.Quit
+		return m.quit()
 	case "enter":
 		selected := m.table.Cursor()
 		if selected < len(m.tools) {
 			if !m.tools[selected].Installed {
-				return m, m.installTool(m.tools[selected])
+				if m.dryRun {
+					return m.showPlans([]AITool{m.tools[selected]})
+				}
+				m.installAllMode = false
+				return m.startInstall([]AITool{m.tools[selected]})
 			} else {
 				m.message = "Tool already installed"
 			}
 		}
+	case "l", "L":
+		selected := m.table.Cursor()
+		if selected < len(m.tools) {
+			return m.openLastLog(m.tools[selected])
+		}
+	case "i", "I":
+		if m.installing {
+			m.mode = "installing"
+			return m, nil
+		}
+	case "g", "G":
+		selected := m.table.Cursor()
+		if selected < len(m.tools) {
+			tool := m.tools[selected]
+			if !tool.Installed {
+				m.message = "Tool is not installed"
+				return m, nil
+			}
+			return m.startUpgrade(tool)
+		}
+	case "u", "U":
+		selected := m.table.Cursor()
+		if selected < len(m.tools) {
+			tool := m.tools[selected]
+			if !tool.Installed {
+				m.message = "Tool is not installed"
+				return m, nil
+			}
+			m.askConfirm(fmt.Sprintf("Uninstall %s?", tool.Name), func() tea.Msg { return uninstallConfirmedMsg{tool: tool} })
+			return m, nil
+		}
 	case "m", "M":
 		selected := m.table.Cursor()
 		if selected < len(m.tools) && len(m.tools[selected].MCPServers) > 0 {
-			return m, m.configureMCPServers(m.tools[selected])
+			if m.dryRun {
+				return m.showMCPPlan([]AITool{m.tools[selected]})
+			}
+			return m.confirmMCPChanges(m.tools[selected].Name, []AITool{m.tools[selected]})
 		}
+	case "p", "P":
+		m.dryRun = !m.dryRun
+		if m.dryRun {
+			m.message = "Plan mode on: Enter, M and install all show what they would do"
+		} else {
+			m.message = "Plan mode off"
+		}
+		return m, nil
 	case "r", "R":
 		return m, checkInstallations(m.tools)
 	}
@@ -82,9 +134,93 @@ func (m Model) handleMCPInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc", "q":
 		m.mode = "menu"
 		return m, nil
+	case "up", "k":
+		if m.mcpCursor > 0 {
+			m.mcpCursor--
+		}
+	case "down", "j":
+		if m.mcpCursor < len(mcpServerRows(m.tools))-1 {
+			m.mcpCursor++
+		}
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		return m.toggleMCPClientAt(int(msg.String()[0] - '0')), nil
+	case "b", "B":
+		m = m.loadMCPBackups()
+		m.backupCursor = 0
+		m.mode = "backups"
 	case "a", "A":
 		// Install all MCP servers
-		return m, m.installAllMCPServers()
+		if m.dryRun {
+			return m.showMCPPlan(m.tools)
+		}
+		count := len(mcpServerRows(m.tools))
+		if count == 0 {
+			m.message = errorStyle.Render("✗ No MCP servers to configure")
+			return m, nil
+		}
+		return m.confirmMCPChanges(fmt.Sprintf("all (%d servers)", count), m.tools)
+	}
+	return m, nil
+}
+
+func (m Model) handleBackupsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = "mcp"
+	case "up", "k":
+		if m.backupCursor > 0 {
+			m.backupCursor--
+		}
+	case "down", "j":
+		if m.backupCursor < len(m.mcpBackups)-1 {
+			m.backupCursor++
+		}
+	case "enter":
+		if m.backupCursor < len(m.mcpBackups) {
+			row := m.mcpBackups[m.backupCursor]
+			m.askConfirm(fmt.Sprintf("Restore %s from the backup of %s?\n\n  %s\n\nThe current version is backed up first.",
+				row.target.App(), row.backup.Time.Format("2006-01-02 15:04:05"), row.target.Path()), restoreMCPBackup(row))
+		}
+	}
+	return m, nil
+}
+
+func (m Model) handleErrorsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m.quit()
+	case "c", "C", "enter":
+		if len(m.tools) > 0 {
+			m.mode = "table"
+			m.updateTable()
+		}
+	}
+	return m, nil
+}
+
+// askConfirm switches to the confirmation dialog, running onYes if accepted
+func (m *Model) askConfirm(prompt string, onYes tea.Cmd) {
+	back := m.mode
+	if m.mode == "confirm" && m.confirm != nil {
+		back = m.confirm.back
+	}
+	m.confirm = &confirmation{prompt: prompt, onYes: onYes, back: back}
+	m.mode = "confirm"
+}
+
+func (m Model) handleConfirmInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+	case "y", "Y":
+		cmd := m.confirm.onYes
+		m.mode = m.confirm.back
+		m.confirm = nil
+		return m, cmd
+	case "n", "N", "esc", "q":
+		m.mode = m.confirm.back
+		m.confirm = nil
+		m.message = "Cancelled"
 	}
 	return m, nil
 }
@@ -92,110 +228,431 @@ func (m Model) handleMCPInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func checkInstallations(tools []AITool) tea.Cmd {
 	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
 		for i := range tools {
-			tools[i].Installed = isInstalled(tools[i])
+			tools[i].Installed, tools[i].Version = detectTool(tools[i])
 		}
+		checkPrerequisites(tools)
 		return checkCompleteMsg{}
 	})
 }
 
+func checkLatest(tools []AITool) tea.Cmd {
+	// Copy so the lookup does not race with updates to the model's tools
+	snapshot := append([]AITool(nil), tools...)
+	return func() tea.Msg {
+		return latestVersionsMsg{versions: checkLatestVersions(snapshot)}
+	}
+}
+
 func isInstalled(tool AITool) bool {
+	installed, _ := detectTool(tool)
+	return installed
+}
+
+// detectTool runs the tool's check command and returns whether it succeeded
+// together with the version found in its output.
+func detectTool(tool AITool) (bool, string) {
 	if tool.CheckCmd == "" {
-		cmd := exec.Command("which", tool.CLICommand)
-		return cmd.Run() == nil
+		_, err := toolExecutable(tool, tool.CLICommand)
+		return err == nil, ""
 	}
 
 	parts := strings.Fields(tool.CheckCmd)
 	if len(parts) == 0 {
-		return false
+		return false, ""
+	}
+	name, err := toolExecutable(tool, parts[0])
+	if err != nil {
+		return false, ""
 	}
-	cmd := exec.Command(parts[0], parts[1:]...)
-	return cmd.Run() == nil
+	cmd := exec.Command(name, parts[1:]...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return false, ""
+	}
+	return true, extractVersion(tool, string(output))
 }
 
-func (m Model) installSelected() tea.Cmd {
-	return func() tea.Msg {
-		for _, tool := range m.tools {
-			if !tool.Installed {
-				return m.installTool(tool)()
-			}
+// startInstallAll installs every missing tool through the install pool
+func (m Model) startInstallAll() (tea.Model, tea.Cmd) {
+	var missing []AITool
+	for _, tool := range m.tools {
+		if !tool.Installed {
+			missing = append(missing, tool)
 		}
-		return installMsg{success: false, err: fmt.Errorf("all tools are already installed")}
 	}
+
+	if len(missing) == 0 {
+		m.message = errorStyle.Render("✗ All tools are already installed")
+		return m, nil
+	}
+	if m.dryRun {
+		return m.showPlans(missing)
+	}
+
+	m.installAllMode = true
+	return m.startInstall(missing)
 }
 
-func (m Model) installAll() tea.Cmd {
-	return func() tea.Msg {
-		m.installing = true
-		m.installAllMode = true
+// poolJob is what the install pool runs for each tool
+type poolJob func(ctx context.Context, tool AITool, out io.Writer, review scriptReviewer) error
 
-		var cmds []tea.Cmd
-		for _, tool := range m.tools {
-			if !tool.Installed {
-				cmds = append(cmds, m.installTool(tool))
-			}
+// poolActions words the progress of each kind of pool run
+var poolActions = map[string]struct{ running, done string }{
+	"install":   {"Installing", "installed"},
+	"upgrade":   {"Upgrading", "upgraded"},
+	"uninstall": {"Uninstalling", "uninstalled"},
+}
+
+// startInstall runs the install pool for tools, reporting each tool's
+// progress and output back to Update as it happens.
+func (m Model) startInstall(tools []AITool) (tea.Model, tea.Cmd) {
+	return m.startPool("install", tools, runInstall)
+}
+
+// startUpgrade upgrades a tool through the install pool, so that it can be
+// cancelled and its output is shown and logged like an install's
+func (m Model) startUpgrade(tool AITool) (tea.Model, tea.Cmd) {
+	return m.startPool("upgrade", []AITool{tool}, func(ctx context.Context, tool AITool, out io.Writer, review scriptReviewer) error {
+		before, after, err := runUpgrade(ctx, tool, out, review)
+		if err == nil {
+			fmt.Fprintf(out, "%s upgraded: %s\n", tool.Name, describeUpgrade(before, after))
 		}
+		return err
+	})
+}
+
+// startUninstall uninstalls a tool through the install pool
+func (m Model) startUninstall(tool AITool) (tea.Model, tea.Cmd) {
+	return m.startPool("uninstall", []AITool{tool}, func(ctx context.Context, tool AITool, out io.Writer, _ scriptReviewer) error {
+		return runUninstall(ctx, tool, out)
+	})
+}
+
+// startPool runs job for tools in the install pool. action is a key of
+// poolActions.
+func (m Model) startPool(action string, tools []AITool, job poolJob) (tea.Model, tea.Cmd) {
+	if m.installing {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ %s is still running", strings.ToLower(poolActions[m.poolAction].running)))
+		return m, nil
+	}
 
-		if len(cmds) == 0 {
-			return installMsg{success: false, err: fmt.Errorf("all tools are already installed")}
+	m.mode = "installing"
+	m.installing = true
+	m.poolAction = action
+	m.progress = make(map[string]installEvent)
+	m.logLines = nil
+	m.logView.SetContent("")
+	ready, blocked := tools, map[string]string{}
+	if action == "install" {
+		ready, blocked = prepareInstall(tools, m.tools)
+	}
+	for _, tool := range tools {
+		if reason, ok := blocked[tool.Name]; ok {
+			m.progress[tool.Name] = installEvent{tool: tool, state: "blocked", err: errors.New(reason)}
+			m.appendLog(errorStyle.Render(fmt.Sprintf("✗ Cannot install %s: %s", tool.Name, reason)))
+			continue
 		}
+		m.progress[tool.Name] = installEvent{tool: tool, state: "queued"}
+	}
+	running := poolActions[action].running
+	if len(tools) == 1 {
+		m.message = fmt.Sprintf("%s %s...", running, tools[0].Name)
+	} else {
+		m.message = fmt.Sprintf("%s %d tools...", running, len(tools))
+	}
+	m.updateTable()
 
-		// Execute installations sequentially
-		for _, cmd := range cmds {
-			result := cmd()
-			if msg, ok := result.(installMsg); ok && !msg.success {
-				return msg
-			}
+	config := loadAppConfig()
+	ctx, cancel := withTimeout(context.Background(), parseTimeout(config.TotalTimeout, 0))
+	m.cancelInstall = cancel
+
+	events := make(chan installEvent)
+	run := func(ctx context.Context, tool AITool, out io.Writer) error {
+		return job(ctx, tool, out, reviewInTUI(events))
+	}
+	go runInstallPool(ctx, ready, config.Parallelism, config.toolTimeout, run, events)
+	return m, waitForInstallEvent(events)
+}
+
+// quit exits the TUI. Running installs are cancelled first so that their
+// processes do not outlive it; the TUI exits once the pool has stopped.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	if !m.installing {
+		return m, tea.Quit
+	}
+	m.cancelInstall()
+	m.quitting = true
+	m.mode = "installing"
+	m.message = "Cancelling installs before quitting..."
+	return m, nil
+}
+
+// appendLog adds a line to the install pane, following the output unless
+// the user has scrolled up
+func (m *Model) appendLog(line string) {
+	const maxLogLines = 2000
+
+	follow := m.logView.AtBottom()
+	m.logLines = append(m.logLines, line)
+	if len(m.logLines) > maxLogLines {
+		m.logLines = m.logLines[len(m.logLines)-maxLogLines:]
+	}
+	m.logView.SetContent(strings.Join(m.logLines, "\n"))
+	if follow {
+		m.logView.GotoBottom()
+	}
+}
+
+// openLastLog shows the most recent install log of a tool
+func (m Model) openLastLog(tool AITool) (tea.Model, tea.Cmd) {
+	path, err := lastInstallLog(tool)
+	if err != nil {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ %v", err))
+		return m, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ %v", err))
+		return m, nil
+	}
+
+	m.logBack = m.mode
+	m.mode = "log"
+	m.logTitle = "Install Log"
+	m.logHeader = path
+	m.logView.SetContent(string(data))
+	m.logView.GotoBottom()
+	return m, nil
+}
+
+// showPlans shows what installing tools would do, including the MCP
+// servers that would be written afterwards
+func (m Model) showPlans(tools []AITool) (tea.Model, tea.Cmd) {
+	var b strings.Builder
+	writePlans(&b, "text", planBatch(tools, m.tools, m.mcpTargets()))
+	return m.showText("Plan", "Dry run: nothing has been installed", b.String())
+}
+
+// showMCPPlan shows the MCP server entries that would be written for tools
+func (m Model) showMCPPlan(tools []AITool) (tea.Model, tea.Cmd) {
+	changes, err := planMCP(m.mcpTargets(), loadAppConfig(), tools)
+	if err != nil {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ %v", err))
+		return m, nil
+	}
+	if len(changes) == 0 {
+		m.message = errorStyle.Render("✗ No MCP servers to configure")
+		return m, nil
+	}
+
+	var b strings.Builder
+	writeMCPChanges(&b, "", changes)
+	return m.showText("MCP Plan", "Dry run: no client config has been modified", b.String())
+}
+
+// showText shows text in the scrollable "log" view
+func (m Model) showText(title, header, text string) (tea.Model, tea.Cmd) {
+	m.logBack = m.mode
+	m.mode = "log"
+	m.logTitle = title
+	m.logHeader = header
+	m.logView.SetContent(text)
+	m.logView.GotoTop()
+	return m, nil
+}
+
+func (m Model) handleInstallingInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m.quit()
+	case "c", "C":
+		if m.installing {
+			m.cancelInstall()
+			m.message = "Cancelling installs..."
 		}
+		return m, nil
+	case "esc":
+		// Installs keep running; their progress shows in the table
+		m.mode = "table"
+		m.updateTable()
+		return m, nil
+	}
 
-		return installMsg{success: true}
+	var cmd tea.Cmd
+	m.logView, cmd = m.logView.Update(msg)
+	return m, cmd
+}
+
+func (m Model) handleLogInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+	case "q", "esc":
+		m.mode = m.logBack
+		// Restore the live install output if an install is running
+		m.logView.SetContent(strings.Join(m.logLines, "\n"))
+		m.logView.GotoBottom()
+		return m, nil
 	}
+
+	var cmd tea.Cmd
+	m.logView, cmd = m.logView.Update(msg)
+	return m, cmd
 }
 
-func (m Model) installTool(tool AITool) tea.Cmd {
-	return func() tea.Msg {
-		m.installing = true
-		m.message = fmt.Sprintf("Installing %s...", tool.Name)
-
-		// If tool has a GitHub repo, clone and install from there
-		if tool.GitHubRepo != "" {
-			if err := m.installFromGitHub(tool); err == nil {
-				return installMsg{
-					tool:    tool,
-					success: true,
-					err:     nil,
-				}
-			}
+// reviewInTUI asks the TUI to approve installers by sending "review"
+// events into the install pool's event stream
+func reviewInTUI(events chan<- installEvent) scriptReviewer {
+	return func(ctx context.Context, r scriptReview) error {
+		reply := make(chan bool, 1)
+		select {
+		case events <- installEvent{tool: r.tool, state: "review", review: &r, reply: reply}:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 
-		// Fallback to standard install command
-		parts := strings.Fields(tool.InstallCmd)
-		if len(parts) == 0 {
-			return installMsg{
-				tool:    tool,
-				success: false,
-				err:     fmt.Errorf("no install command specified"),
+		select {
+		case approved := <-reply:
+			if !approved {
+				return fmt.Errorf("%s was not approved", r.file)
 			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	}
+}
+
+// showReview shows the first installer waiting for approval
+func (m *Model) showReview() {
+	if len(m.reviews) == 0 {
+		return
+	}
+	r := m.reviews[0].review
+	m.mode = "review"
+	m.logHeader = r.describe()
+	m.logView.SetContent(string(r.content))
+	m.logView.GotoTop()
+}
+
+func (m Model) handleReviewInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var approved bool
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+	case "y", "Y":
+		approved = true
+	case "n", "N", "esc":
+	default:
+		var cmd tea.Cmd
+		m.logView, cmd = m.logView.Update(msg)
+		return m, cmd
+	}
+
+	m.reviews[0].reply <- approved
+	m.reviews = m.reviews[1:]
+	if len(m.reviews) > 0 {
+		m.showReview()
+		return m, nil
+	}
+
+	m.mode = "installing"
+	m.logView.SetContent(strings.Join(m.logLines, "\n"))
+	m.logView.GotoBottom()
+	return m, nil
+}
 
-		cmd := exec.Command(parts[0], parts[1:]...)
-		err := cmd.Run()
+func waitForInstallEvent(events chan installEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return installPoolDoneMsg{}
+		}
+		return installProgressMsg{event: event, events: events}
+	}
+}
 
-		return installMsg{
-			tool:    tool,
-			success: err == nil,
-			err:     err,
+// installPoolSummary describes the outcome of the last install pool run
+func (m Model) installPoolSummary() string {
+	var installed, cancelled int
+	var failed, blocked []string
+	for _, event := range m.progress {
+		switch event.state {
+		case "installed":
+			installed++
+		case "failed":
+			failed = append(failed, event.tool.Name)
+		case "blocked":
+			blocked = append(blocked, event.tool.Name)
+		case "cancelled":
+			cancelled++
 		}
 	}
+	sort.Strings(failed)
+	sort.Strings(blocked)
+
+	done := poolActions[m.poolAction].done
+	summary := fmt.Sprintf("%s %d tools", strings.ToUpper(done[:1])+done[1:], installed)
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(", %d failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	if len(blocked) > 0 {
+		summary += fmt.Sprintf(", %d missing prerequisites: %s", len(blocked), strings.Join(blocked, ", "))
+	}
+	if cancelled > 0 {
+		summary += fmt.Sprintf(", %d cancelled", cancelled)
+	}
+	if len(failed) == 0 && len(blocked) == 0 && cancelled == 0 {
+		return successStyle.Render("✓ " + summary)
+	}
+	return errorStyle.Render("✗ " + summary)
+}
+
+var progressLabels = map[string]string{
+	"queued":    "… Queued",
+	"failed":    "✗ Failed",
+	"blocked":   "⚠ Missing prerequisite",
+	"cancelled": "⊘ Cancelled",
+}
+
+// progressLabel shows a tool's state in the running pool
+func (m Model) progressLabel(state string) string {
+	action := poolActions[m.poolAction]
+	switch state {
+	case "installing":
+		return "⏳ " + action.running
+	case "installed":
+		return "✅ " + strings.ToUpper(action.done[:1]) + action.done[1:]
+	}
+	return progressLabels[state]
 }
 
 func (m *Model) updateTable() {
 	rows := []table.Row{}
 	for i, tool := range m.tools {
-		status := "Not installed"
-		if tool.Installed {
+		locked, drift := lockDrift(tool, m.lock)
+
+		status := "❌ Missing"
+		if event, ok := m.progress[tool.Name]; ok && m.installing {
+			status = m.progressLabel(event.state)
+		} else if drift {
+			status = "⚠ Lock drift"
+		} else if isOutdated(tool) {
+			status = "⬆ Outdated"
+		} else if tool.Installed {
 			status = "✅ Installed"
-		} else {
-			status = "❌ Missing"
+		} else if len(tool.Missing) > 0 {
+			status = "⚠ Missing prerequisite"
+		}
+
+		version := "-"
+		if tool.Version != "" {
+			version = tool.Version
+		}
+		if drift {
+			version = fmt.Sprintf("%s ≠ %s", tool.Version, locked)
+		} else if isOutdated(tool) {
+			version = fmt.Sprintf("%s → %s", tool.Version, tool.Latest)
 		}
 
 		mcpStatus := "-"
@@ -205,8 +662,8 @@ func (m *Model) updateTable() {
 
 		// Truncate description if too long
 		description := tool.Description
-		if len(description) > 35 {
-			description = description[:32] + "..."
+		if len(description) > 30 {
+			description = description[:27] + "..."
 		}
 
 		rows = append(rows, table.Row{
@@ -214,9 +671,10 @@ func (m *Model) updateTable() {
 			tool.Name,
 			tool.CLICommand,
 			status,
+			version,
 			mcpStatus,
 			description,
 		})
 	}
 	m.table.SetRows(rows)
-}
\ No newline at end of file
+}
@@ -0,0 +1,137 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runInstall installs a tool, writing the output of every command it runs
+// to out. It is shared by the TUI and the headless install command.
+// Cancelling ctx stops the running command. review approves installers
+// from cloned repositories; nil only allows pre-approved ones.
+func runInstall(ctx context.Context, tool AITool, out io.Writer, review scriptReviewer) error {
+	// Typed install methods take precedence; the first usable one is run
+	var methodErr error
+	if len(tool.InstallMethods) > 0 {
+		method, err := selectInstallMethod(tool)
+		if err == nil {
+			if isolated := isolatePip(method); isolated.Type != method.Type {
+				fmt.Fprintf(out, "Cannot create a virtual environment, installing %s with %s\n", method.Package, isolated.Type)
+				method = isolated
+			}
+			pinned, err := method.pinned(tool.Constraint)
+			if err != nil {
+				return err
+			}
+			prefix := managedPrefix(tool, method)
+			if err := runInstallMethod(ctx, tool, pinned, prefix, out, review); err != nil {
+				return err
+			}
+			return finishInstall(tool, newInstallRecord(method, prefix), out)
+		}
+		methodErr = err
+		if tool.GitHubRepo != "" || tool.InstallCmd != "" {
+			fmt.Fprintf(out, "%v, falling back\n", err)
+		}
+	}
+
+	if tool.Constraint != "" {
+		fmt.Fprintf(out, "Warning: version %s of %s cannot be pinned without an install method\n", tool.Constraint, tool.Name)
+	}
+
+	// If tool has a GitHub repo, clone and install from there
+	if tool.GitHubRepo != "" {
+		commit, err := installFromGitHub(ctx, tool, out, review)
+		if err == nil {
+			return finishInstall(tool, installRecord{Method: "github", Repo: tool.GitHubRepo, Commit: commit}, out)
+		}
+		if tool.InstallCmd == "" {
+			return err
+		}
+		fmt.Fprintf(out, "GitHub install of %s failed: %v, falling back\n", tool.Name, err)
+	}
+
+	// Fallback to standard install command
+	parts := strings.Fields(tool.InstallCmd)
+	if len(parts) == 0 {
+		if methodErr != nil {
+			return methodErr
+		}
+		return fmt.Errorf("no install command specified")
+	}
+
+	if err := runCommand(ctx, out, parts); err != nil {
+		return err
+	}
+	return finishInstall(tool, installRecord{Method: "command", Command: tool.InstallCmd}, out)
+}
+
+// runCommand runs argv, echoing it and its output to out
+func runCommand(ctx context.Context, out io.Writer, argv []string) error {
+	if argv[0] == "sudo" {
+		if err := checkSudo(ctx); err != nil {
+			return err
+		}
+	}
+	return runInDir(ctx, out, "", argv[0], argv[1:]...)
+}
+
+// runInDir runs a command in dir. When ctx is done the command and every
+// process it started are terminated.
+func runInDir(ctx context.Context, out io.Writer, dir string, name string, args ...string) error {
+	return runWithEnv(ctx, out, dir, nil, name, args...)
+}
+
+// runWithEnv is runInDir with extra KEY=value environment variables
+func runWithEnv(ctx context.Context, out io.Writer, dir string, env []string, name string, args ...string) error {
+	fmt.Fprintf(out, "$ %s\n", strings.Join(append(append(append([]string{}, env...), name), args...), " "))
+	cmd := exec.CommandContext(ctx, name, args...)
+	waited := killProcessGroup(cmd)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	waited()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// newInstallRecord describes an install with method into prefix
+func newInstallRecord(method InstallMethod, prefix string) installRecord {
+	record := installRecord{Method: method.Type, Spec: &method, Prefix: prefix}
+	if method.Type == "pip" && prefix != "" {
+		record.Venv = prefixVenv(prefix)
+	}
+	return record
+}
+
+// finishInstall records a successful install. Failing to record it does not
+// undo the install, so it is only reported.
+func finishInstall(tool AITool, record installRecord, out io.Writer) error {
+	if err := recordInstall(tool, record); err != nil {
+		fmt.Fprintf(out, "Warning: could not record how %s was installed: %v\n", tool.Name, err)
+	}
+	if (record.Prefix != "" || record.Method == "binary") && !onPath(managedBinDir()) {
+		fmt.Fprintf(out, "Note: %s is not on PATH, run `ai-cli-manager doctor` for how to add it\n", managedBinDir())
+	}
+	return nil
+}
+
+// findTool looks a tool up by name or CLI command, ignoring case
+func findTool(tools []AITool, name string) (int, bool) {
+	for i, tool := range tools {
+		if strings.EqualFold(tool.Name, name) || strings.EqualFold(tool.CLICommand, name) {
+			return i, true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,150 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const lockFileName = "ai-tools.lock"
+
+// lockEntry records exactly what is installed for one tool
+type lockEntry struct {
+	Version string         `json:"version"`
+	Method  string         `json:"method"` // an install method type, "github" or "command"
+	Spec    *InstallMethod `json:"spec,omitempty"`
+	Repo    string         `json:"repo,omitempty"`
+	Commit  string         `json:"commit,omitempty"` // commit a github install was built from
+	Command string         `json:"command,omitempty"`
+}
+
+type lockFile struct {
+	LockVersion int                  `json:"lock_version"`
+	Tools       map[string]lockEntry `json:"tools"`
+}
+
+// lockPath places the lockfile next to the project catalog, or in the
+// current directory when there is none.
+func lockPath() string {
+	if project := projectToolsPath(); project != "" {
+		return filepath.Join(filepath.Dir(project), lockFileName)
+	}
+	return lockFileName
+}
+
+// loadLock reads the lockfile, returning nil when there is none
+func loadLock() (*lockFile, error) {
+	data, err := os.ReadFile(lockPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lock lockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", lockPath(), err)
+	}
+	if lock.LockVersion != 1 {
+		return nil, fmt.Errorf("%s: unsupported lock_version %d", lockPath(), lock.LockVersion)
+	}
+	return &lock, nil
+}
+
+func writeLock(lock *lockFile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(lockPath(), append(data, '\n'), 0644)
+}
+
+// generateLock records the installed version and install method of every
+// installed tool. Tools whose version or method cannot be determined are
+// reported to out and left out of the lock, and so are those whose install
+// cannot be reproduced exactly.
+func generateLock(tools []AITool, out io.Writer) *lockFile {
+	lock := &lockFile{LockVersion: 1, Tools: make(map[string]lockEntry)}
+	state := loadInstallState()
+
+	for _, tool := range tools {
+		installed, version := detectTool(tool)
+		if !installed {
+			continue
+		}
+		if version == "" {
+			fmt.Fprintf(out, "Skipping %s: installed version unknown\n", tool.Name)
+			continue
+		}
+
+		entry := lockEntry{Version: version}
+		if record, ok := state.Tools[tool.Name]; ok {
+			entry.Method = record.Method
+			entry.Spec = record.Spec
+			entry.Repo = record.Repo
+			entry.Commit = record.Commit
+			entry.Command = record.Command
+		} else if method, err := installedMethod(tool); err == nil {
+			entry.Method = method.Type
+			entry.Spec = &method
+		} else {
+			fmt.Fprintf(out, "Skipping %s: %v\n", tool.Name, err)
+			continue
+		}
+		if _, err := lockedTool(tool, entry); err != nil {
+			fmt.Fprintf(out, "Skipping %s: %v\n", tool.Name, err)
+			continue
+		}
+		lock.Tools[tool.Name] = entry
+	}
+
+	return lock
+}
+
+// lockedTool returns a copy of the tool that installs exactly what the lock
+// entry records, or an error when its install cannot be pinned
+func lockedTool(tool AITool, entry lockEntry) (AITool, error) {
+	tool.InstallMethods = nil
+	tool.GitHubRepo = ""
+	tool.GitHubRef = ""
+	tool.GitHubCommit = ""
+	tool.InstallCmd = ""
+	tool.Constraint = ""
+
+	switch entry.Method {
+	case "github":
+		if entry.Commit == "" {
+			return tool, fmt.Errorf("installed from %s without a recorded commit; reinstall it and run lock again", entry.Repo)
+		}
+		tool.GitHubRepo = entry.Repo
+		tool.GitHubCommit = entry.Commit
+	case "command":
+		return tool, fmt.Errorf("installed with %q, which cannot install a specific version; give the tool an install method", entry.Command)
+	default:
+		if entry.Spec == nil {
+			return tool, fmt.Errorf("no install method recorded for %s", entry.Method)
+		}
+		if _, err := entry.Spec.pinned(entry.Version); err != nil {
+			return tool, err
+		}
+		tool.InstallMethods = []InstallMethod{*entry.Spec}
+		tool.Constraint = entry.Version
+	}
+	return tool, nil
+}
+
+// lockDrift returns the locked version when the installed tool differs from it
+func lockDrift(tool AITool, lock *lockFile) (string, bool) {
+	if lock == nil || !tool.Installed {
+		return "", false
+	}
+	entry, ok := lock.Tools[tool.Name]
+	if !ok || tool.Version == "" {
+		return "", false
+	}
+	return entry.Version, strings.TrimPrefix(tool.Version, "v") != strings.TrimPrefix(entry.Version, "v")
+}
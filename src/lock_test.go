@@ -0,0 +1,68 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLockedTool(t *testing.T) {
+	catalog := AITool{
+		Name:       "Tool",
+		CLICommand: "tool",
+		GitHubRepo: "https://github.com/example/tool",
+		GitHubRef:  "main",
+		InstallCmd: "curl -fsSL https://example.com/install | sh",
+		Constraint: ">=1",
+	}
+	commit := strings.Repeat("a", 40)
+
+	tool, err := lockedTool(catalog, lockEntry{Version: "1.2.3", Method: "github", Repo: catalog.GitHubRepo, Commit: commit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.GitHubCommit != commit || tool.GitHubRef != "" || tool.InstallCmd != "" || tool.Constraint != "" {
+		t.Errorf("locked github tool = %+v, want only the repo at %s", tool, commit)
+	}
+
+	spec := &InstallMethod{Type: "npm", Package: "tool"}
+	tool, err = lockedTool(catalog, lockEntry{Version: "v1.2.3", Method: "npm", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tool.InstallMethods) != 1 || tool.Constraint != "v1.2.3" || tool.GitHubRepo != "" {
+		t.Errorf("locked npm tool = %+v", tool)
+	}
+
+	failures := []lockEntry{
+		{Version: "1.2.3", Method: "github", Repo: catalog.GitHubRepo},
+		{Version: "1.2.3", Method: "command", Command: catalog.InstallCmd},
+		{Version: "1.2.3", Method: "binary", Spec: &InstallMethod{Type: "binary", URL: "https://example.com/tool"}},
+		{Version: "1.2.3", Method: "npm"},
+	}
+	for _, entry := range failures {
+		if _, err := lockedTool(catalog, entry); err == nil {
+			t.Errorf("locking %+v succeeded, want an error", entry)
+		}
+	}
+}
+
+func TestLockDrift(t *testing.T) {
+	lock := &lockFile{Tools: map[string]lockEntry{"Tool": {Version: "1.2.3"}}}
+	tests := []struct {
+		installed bool
+		version   string
+		want      bool
+	}{
+		{true, "1.2.3", false},
+		{true, "v1.2.3", false},
+		{true, "1.2.4", true},
+		{true, "", false},
+		{false, "1.0.0", false},
+	}
+	for _, tt := range tests {
+		tool := AITool{Name: "Tool", Installed: tt.installed, Version: tt.version}
+		if _, got := lockDrift(tool, lock); got != tt.want {
+			t.Errorf("lockDrift(installed %v, %q) = %v, want %v", tt.installed, tt.version, got, tt.want)
+		}
+	}
+}
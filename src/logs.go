@@ -0,0 +1,73 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// toolLogDir is where the install logs of a tool are kept
+func toolLogDir(tool AITool) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "logs", unsafeNameChars.ReplaceAllString(tool.Name, "-"))
+}
+
+// createInstallLog opens a new timestamped log file for one run
+func createInstallLog(tool AITool) (*os.File, error) {
+	dir := toolLogDir(tool)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := time.Now().Format("20060102-150405.000") + ".log"
+	return os.Create(filepath.Join(dir, name))
+}
+
+// lastInstallLog returns the most recent log file of a tool
+func lastInstallLog(tool AITool) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(toolLogDir(tool), "*.log"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no install logs for %s", tool.Name)
+	}
+	// Timestamped names sort chronologically
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// lineWriter splits written output into lines and hands each one to emit.
+// Carriage returns used by progress bars start a new line as well.
+type lineWriter struct {
+	emit func(string)
+	buf  bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(bytes.ReplaceAll(p, []byte("\r"), []byte("\n")))
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			w.buf.Write(line)
+			return len(p), nil
+		}
+		if trimmed := bytes.TrimRight(line, "\n"); len(trimmed) > 0 {
+			w.emit(string(trimmed))
+		}
+	}
+}
+
+// Flush emits any trailing output that did not end in a newline
+func (w *lineWriter) Flush() {
+	if w.buf.Len() > 0 {
+		w.emit(w.buf.String())
+		w.buf.Reset()
+	}
+}
@@ -0,0 +1,62 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{"whole lines", []string{"one\ntwo\n"}, []string{"one", "two"}},
+		{"line split across writes", []string{"Down", "load", "ing\nDone\n"}, []string{"Downloading", "Done"}},
+		{"newline in its own write", []string{"a", "\n", "b", "\n"}, []string{"a", "b"}},
+		{"progress bar", []string{"10%\r50%", "\r100%\n"}, []string{"10%", "50%", "100%"}},
+		{"crlf", []string{"one\r\ntwo\r", "\n"}, []string{"one", "two"}},
+		{"blank lines dropped", []string{"\n\none\n\n"}, []string{"one"}},
+		{"unterminated tail flushed", []string{"first\nlast"}, []string{"first", "last"}},
+		{"nothing", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			w := &lineWriter{emit: func(line string) { got = append(got, line) }}
+			for _, chunk := range tt.chunks {
+				n, err := w.Write([]byte(chunk))
+				if n != len(chunk) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+				}
+			}
+			w.Flush()
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+
+			// Flushing again emits nothing more
+			before := len(got)
+			w.Flush()
+			if len(got) != before {
+				t.Errorf("second Flush emitted %q", got[before:])
+			}
+		})
+	}
+}
+
+func TestLineWriterByteAtATime(t *testing.T) {
+	var got []string
+	w := &lineWriter{emit: func(line string) { got = append(got, line) }}
+	for _, b := range []byte("alpha\nbeta\r\ngamma") {
+		w.Write([]byte{b})
+	}
+	if len(got) != 2 {
+		t.Fatalf("before Flush, lines = %q, want the two complete ones", got)
+	}
+	w.Flush()
+	if strings.Join(got, ",") != "alpha,beta,gamma" {
+		t.Errorf("lines = %q", got)
+	}
+}
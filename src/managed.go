@@ -0,0 +1,165 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// managedTypes are the install methods that can be isolated in a per-tool
+// prefix. pipx and uv already isolate their tools; the others install
+// system packages.
+var managedTypes = map[string]bool{"npm": true, "pip": true, "go": true, "binary": true}
+
+// managedToolsDir holds one prefix per tool when managed_prefix is on
+func managedToolsDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "tools")
+}
+
+func managedToolDir(tool AITool) string {
+	return filepath.Join(managedToolsDir(), unsafeNameChars.ReplaceAllString(tool.Name, "-"))
+}
+
+// managedPrefix returns the prefix tool is installed into with method, or
+// "" when managed installs are off or the method cannot use one. pip
+// installs always get a prefix, for the tool's own virtual environment.
+func managedPrefix(tool AITool, method InstallMethod) string {
+	if method.Type != "pip" && (!loadAppConfig().ManagedPrefix || !managedTypes[method.Type]) {
+		return ""
+	}
+	return managedToolDir(tool)
+}
+
+// managedExecDir is where method puts a tool's executables under prefix
+func managedExecDir(method InstallMethod, prefix string) string {
+	switch method.Type {
+	case "npm":
+		if runtime.GOOS == "windows" {
+			return prefix
+		}
+	case "pip":
+		return venvBinDir(prefixVenv(prefix))
+	}
+	return filepath.Join(prefix, "bin")
+}
+
+// managedCommand rewrites an install or upgrade argv of method to work in
+// prefix, and returns it with the extra environment it needs.
+func managedCommand(method InstallMethod, argv []string, prefix string) ([]string, []string) {
+	switch method.Type {
+	case "npm":
+		return append([]string{argv[0], argv[1], "--prefix", prefix}, argv[2:]...), nil
+	case "pip":
+		python := filepath.Join(venvBinDir(prefixVenv(prefix)), "python")
+		return append([]string{python, "-m", "pip"}, argv[1:]...), nil
+	case "go":
+		return argv, []string{"GOBIN=" + managedExecDir(method, prefix)}
+	}
+	return argv, nil
+}
+
+// installManaged installs tool with method into prefix, or upgrades it
+// there, and links its command into the managed bin directory. A prefix
+// created by a failed install is removed again.
+func installManaged(ctx context.Context, tool AITool, method InstallMethod, prefix string, upgrade bool, out io.Writer) (err error) {
+	if _, statErr := os.Stat(prefix); os.IsNotExist(statErr) {
+		defer func() {
+			if err != nil {
+				os.RemoveAll(prefix)
+			}
+		}()
+	}
+	if err := os.MkdirAll(prefix, 0755); err != nil {
+		return err
+	}
+
+	if method.Type == "binary" {
+		err = installBinary(ctx, tool, method, managedExecDir(method, prefix), out)
+	} else {
+		err = runManagedCommand(ctx, method, prefix, upgrade, out)
+	}
+	if err != nil {
+		return err
+	}
+	return linkShim(tool, method, prefix, out)
+}
+
+func runManagedCommand(ctx context.Context, method InstallMethod, prefix string, upgrade bool, out io.Writer) error {
+	if method.Type == "pip" {
+		if err := ensureVenv(ctx, prefixVenv(prefix), out); err != nil {
+			return err
+		}
+	}
+
+	argv, err := method.command()
+	if upgrade {
+		argv, err = method.upgradeCommand()
+	}
+	if err != nil {
+		return err
+	}
+	argv, env := managedCommand(method, argv, prefix)
+	return runWithEnv(ctx, out, "", env, argv[0], argv[1:]...)
+}
+
+// linkShim points the tool's command in the managed bin directory at the
+// executable installed in prefix
+func linkShim(tool AITool, method InstallMethod, prefix string, out io.Writer) error {
+	target, err := exec.LookPath(filepath.Join(managedExecDir(method, prefix), tool.CLICommand))
+	if err != nil {
+		return fmt.Errorf("%s was not installed into %s", tool.CLICommand, prefix)
+	}
+	if err := os.MkdirAll(managedBinDir(), 0755); err != nil {
+		return err
+	}
+	shim := shimPath(tool.CLICommand)
+	fmt.Fprintf(out, "Linking %s -> %s\n", shim, target)
+	return writeShim(shim, target)
+}
+
+// removeManaged deletes a tool's shim and prefix
+func removeManaged(tool AITool, prefix string, out io.Writer) error {
+	if err := removeFile(shimPath(tool.CLICommand), out); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Removing %s\n", prefix)
+	return os.RemoveAll(prefix)
+}
+
+// onPath reports whether dir is one of the PATH entries
+func onPath(dir string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry == "" {
+			continue
+		}
+		if filepath.Clean(entry) == filepath.Clean(dir) ||
+			(runtime.GOOS == "windows" && strings.EqualFold(filepath.Clean(entry), filepath.Clean(dir))) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookCommand finds a command on PATH, or else among the managed shims
+// and binary downloads, which may not be on PATH yet
+func lookCommand(name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	if managed, managedErr := exec.LookPath(filepath.Join(managedBinDir(), name)); managedErr == nil {
+		return managed, nil
+	}
+	return "", err
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -1,189 +1,457 @@
 package src
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-type ClaudeConfig struct {
-	MCPServers map[string]MCPServerEntry `json:"mcpServers,omitempty"`
-}
-
 type MCPServerEntry struct {
 	Command string            `json:"command"`
 	Args    []string          `json:"args,omitempty"`
 	Env     map[string]string `json:"env,omitempty"`
 }
 
-func (m Model) configureMCPServers(tool AITool) tea.Cmd {
+// applyMCP writes changes the user has confirmed
+func applyMCP(label string, changes []mcpChange) tea.Cmd {
 	return func() tea.Msg {
-		if len(tool.MCPServers) == 0 {
-			return mcpInstallMsg{
-				tool:    tool.Name,
-				success: false,
-				err:     fmt.Errorf("no MCP servers configured for %s", tool.Name),
-			}
-		}
-
-		// Read existing Claude config
-		config, err := m.readClaudeConfig()
-		if err != nil {
-			config = &ClaudeConfig{
-				MCPServers: make(map[string]MCPServerEntry),
-			}
-		}
-
-		// Add MCP servers for this tool
-		for _, server := range tool.MCPServers {
-			serverKey := fmt.Sprintf("%s-%s", tool.Name, server.Name)
-			config.MCPServers[serverKey] = MCPServerEntry{
-				Command: server.Command,
-				Args:    server.Args,
-				Env:     server.Env,
-			}
-		}
-
-		// Write updated config
-		if err := m.writeClaudeConfig(config); err != nil {
+		if err := applyMCPChanges(changes); err != nil {
 			return mcpInstallMsg{
-				tool:    tool.Name,
+				tool:    label,
 				success: false,
 				err:     err,
 			}
 		}
-
 		return mcpInstallMsg{
-			tool:    tool.Name,
+			tool:    label + mcpAppsSummary(changes),
 			success: true,
 		}
 	}
 }
 
-func (m Model) installAllMCPServers() tea.Cmd {
-	return func() tea.Msg {
-		config, err := m.readClaudeConfig()
-		if err != nil {
-			config = &ClaudeConfig{
-				MCPServers: make(map[string]MCPServerEntry),
-			}
+// confirmMCPChanges shows what deploying the tools' servers would change
+// and applies it once confirmed
+func (m Model) confirmMCPChanges(label string, tools []AITool) (tea.Model, tea.Cmd) {
+	pending, err := m.offerMCPChanges(label, tools)
+	switch {
+	case err != nil:
+		m.message = errorStyle.Render(fmt.Sprintf("✗ MCP configuration failed: %v", err))
+	case !pending:
+		m.message = successStyle.Render(fmt.Sprintf("✓ MCP servers for %s are up to date", label))
+	}
+	return m, nil
+}
+
+// offerMCPChanges asks to apply what deploying the tools' servers would
+// change, and reports whether there was anything to ask about
+func (m *Model) offerMCPChanges(label string, tools []AITool) (bool, error) {
+	changes, err := planMCP(m.mcpTargets(), loadAppConfig(), tools)
+	if err != nil {
+		return false, err
+	}
+	pending := pendingMCPChanges(changes)
+	if len(pending) == 0 {
+		return false, nil
+	}
+
+	var b strings.Builder
+	writeMCPChanges(&b, "", pending)
+	m.askConfirm(fmt.Sprintf("Apply these MCP changes for %s?\n\n%s", label, strings.TrimRight(b.String(), "\n")), applyMCP(label, pending))
+	return true, nil
+}
+
+// offerInstalledMCP asks to deploy the servers of tools that were just
+// installed. The install result stays on screen when there is nothing to ask.
+func (m *Model) offerInstalledMCP(tools []AITool) {
+	if len(tools) == 0 {
+		return
+	}
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	if _, err := m.offerMCPChanges(strings.Join(names, ", "), tools); err != nil {
+		m.message += "\n" + errorStyle.Render(fmt.Sprintf("✗ MCP configuration failed: %v", err))
+	}
+}
+
+// mcpAppsSummary names the clients whose config changes touched
+func mcpAppsSummary(changes []mcpChange) string {
+	var apps []string
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if change.Action != "unchanged" && !seen[change.App] {
+			seen[change.App] = true
+			apps = append(apps, change.App)
 		}
+	}
+	if len(apps) == 0 {
+		return ", nothing to change"
+	}
+	return " in " + strings.Join(apps, ", ")
+}
 
-		count := 0
-		for _, tool := range m.tools {
-			if len(tool.MCPServers) > 0 {
-				for _, server := range tool.MCPServers {
-					serverKey := fmt.Sprintf("%s-%s", tool.Name, server.Name)
-					config.MCPServers[serverKey] = MCPServerEntry{
-						Command: server.Command,
-						Args:    server.Args,
-						Env:     server.Env,
-					}
-					count++
-				}
-			}
+// applyMCPChanges writes planned changes, one config file at a time
+func applyMCPChanges(changes []mcpChange) error {
+	var order []mcpTarget
+	sets := make(map[string]map[string]MCPServerEntry)
+	removes := make(map[string][]string)
+	for _, change := range changes {
+		id := change.target.ID()
+		if _, ok := sets[id]; !ok {
+			order = append(order, change.target)
+			sets[id] = make(map[string]MCPServerEntry)
+		}
+		switch change.Action {
+		case "add", "change":
+			sets[id][change.Key] = *change.After
+		case "remove":
+			removes[id] = append(removes[id], change.Key)
 		}
+	}
 
-		if count == 0 {
-			return mcpInstallMsg{
-				tool:    "all",
-				success: false,
-				err:     fmt.Errorf("no MCP servers to configure"),
-			}
+	for _, target := range order {
+		set, remove := sets[target.ID()], removes[target.ID()]
+		if len(set) == 0 && len(remove) == 0 {
+			continue
 		}
+		if err := target.Write(set, remove); err != nil {
+			return fmt.Errorf("%s: %w", target.App(), err)
+		}
+	}
+	return nil
+}
 
-		if err := m.writeClaudeConfig(config); err != nil {
-			return mcpInstallMsg{
-				tool:    "all",
-				success: false,
-				err:     err,
-			}
+// mcpConfigEnv overrides the MCP config file
+const mcpConfigEnv = "AI_CLI_MANAGER_MCP_CONFIG"
+
+// mcpConfigLocation is the MCP config file in use and where its path came from
+type mcpConfigLocation struct {
+	path   string
+	source string // "--mcp-config", mcpConfigEnv, "config.json" or "detected"
+}
+
+// claudeDesktopConfigPath is where Claude Desktop keeps its config on this
+// OS: ~/Library/Application Support on macOS, %APPDATA% on Windows and
+// $XDG_CONFIG_HOME (~/.config) elsewhere.
+func claudeDesktopConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		homeDir, _ := os.UserHomeDir()
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "Claude", "claude_desktop_config.json")
+}
+
+// resolveMCPConfig picks the MCP config file from, in order, the
+// --mcp-config flag, the environment, config.json and the Claude Desktop
+// location for this OS
+func resolveMCPConfig(flagValue string) mcpConfigLocation {
+	if flagValue != "" {
+		return mcpConfigLocation{path: expandHome(flagValue), source: "--mcp-config"}
+	}
+	if path := os.Getenv(mcpConfigEnv); path != "" {
+		return mcpConfigLocation{path: expandHome(path), source: mcpConfigEnv}
+	}
+	if path := loadAppConfig().MCPConfigPath; path != "" {
+		return mcpConfigLocation{path: expandHome(path), source: "config.json"}
+	}
+	return mcpConfigLocation{path: claudeDesktopConfigPath(), source: "detected"}
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		homeDir, _ := os.UserHomeDir()
+		return filepath.Join(homeDir, path[1:])
+	}
+	return path
+}
+
+// mcpConfigOwner names the application that reads the config file at path
+func mcpConfigOwner(path string) string {
+	if filepath.Base(path) == "claude_desktop_config.json" {
+		return "Claude Desktop"
+	}
+	for _, target := range mcpTargets(claudeDesktopConfigPath()) {
+		if filepath.Clean(target.Path()) == filepath.Clean(path) {
+			return target.App()
 		}
+	}
+	return ""
+}
 
-		return mcpInstallMsg{
-			tool:    fmt.Sprintf("all (%d servers)", count),
-			success: true,
+func mcpServerKey(tool AITool, server MCPServerConfig) string {
+	return fmt.Sprintf("%s-%s", tool.Name, server.Name)
+}
+
+// mcpServerRow is one catalog MCP server on the MCP screen
+type mcpServerRow struct {
+	key   string
+	entry MCPServerEntry
+}
+
+func mcpServerRows(tools []AITool) []mcpServerRow {
+	var rows []mcpServerRow
+	for _, tool := range tools {
+		for _, server := range tool.MCPServers {
+			rows = append(rows, mcpServerRow{
+				key:   mcpServerKey(tool, server),
+				entry: MCPServerEntry{Command: server.Command, Args: server.Args, Env: server.Env},
+			})
 		}
 	}
+	return rows
 }
 
-func (m Model) readClaudeConfig() (*ClaudeConfig, error) {
-	data, err := os.ReadFile(m.mcpConfigPath)
-	if err != nil {
-		return nil, err
+// sameMCPEntry compares entries, treating missing and empty args or env alike
+func sameMCPEntry(a, b MCPServerEntry) bool {
+	if a.Command != b.Command || !sameStrings(a.Args, b.Args) || len(a.Env) != len(b.Env) {
+		return false
+	}
+	for key, value := range a.Env {
+		if other, ok := b.Env[key]; !ok || other != value {
+			return false
+		}
 	}
+	return true
+}
 
-	var config ClaudeConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
 	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Model) mcpTargets() []mcpTarget {
+	return mcpTargets(m.mcpConfigPath)
+}
 
-	return &config, nil
+// mcpTargetState is a client's config file as last read
+type mcpTargetState struct {
+	servers map[string]MCPServerEntry
+	err     error
 }
 
-func (m Model) writeClaudeConfig(config *ClaudeConfig) error {
-	// Ensure directory exists
-	dir := filepath.Dir(m.mcpConfigPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+func readMCPTargets(targets []mcpTarget) map[string]mcpTargetState {
+	states := make(map[string]mcpTargetState)
+	for _, target := range targets {
+		servers, err := target.Read()
+		states[target.ID()] = mcpTargetState{servers: servers, err: err}
 	}
+	return states
+}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
+// mcpRemovals lists the tool's servers present in any client's config
+func mcpRemovals(targets []mcpTarget, tool AITool) []mcpChange {
+	var changes []mcpChange
+	for _, target := range targets {
+		servers, err := target.Read()
+		if err != nil {
+			continue
+		}
+		for _, server := range tool.MCPServers {
+			key := mcpServerKey(tool, server)
+			if before, ok := servers[key]; ok {
+				changes = append(changes, mcpChange{
+					Client: target.ID(), App: target.App(), Path: target.Path(),
+					Key: key, Action: "remove", Before: &before, target: target,
+				})
+			}
+		}
 	}
+	return changes
+}
 
-	return os.WriteFile(m.mcpConfigPath, data, 0644)
+// configuredMCPServers describes where the tool's servers are configured,
+// one "<key> (<client>)" per entry
+func configuredMCPServers(targets []mcpTarget, tool AITool) []string {
+	var entries []string
+	for _, change := range mcpRemovals(targets, tool) {
+		entries = append(entries, fmt.Sprintf("%s (%s)", change.Key, change.App))
+	}
+	return entries
 }
 
-func (m Model) viewMCP() string {
-	config, err := m.readClaudeConfig()
+// removeMCPServers deletes the tool's servers from every client's config
+// and returns how many entries were removed
+func removeMCPServers(targets []mcpTarget, tool AITool) (int, error) {
+	changes := mcpRemovals(targets, tool)
+	return len(changes), applyMCPChanges(changes)
+}
 
-	serverCount := 0
-	if err == nil && config != nil {
-		serverCount = len(config.MCPServers)
+func (m Model) removeMCPServers(tool AITool) tea.Cmd {
+	targets := m.mcpTargets()
+	return func() tea.Msg {
+		count, err := removeMCPServers(targets, tool)
+		return mcpRemoveMsg{tool: tool.Name, count: count, err: err}
 	}
+}
+
+// toggleMCPClientAt switches client n (1-based) on or off for the server
+// under the cursor
+func (m Model) toggleMCPClientAt(n int) Model {
+	rows := mcpServerRows(m.tools)
+	targets := m.mcpTargets()
+	if m.mcpCursor >= len(rows) || n < 1 || n > len(targets) {
+		return m
+	}
+	row, target := rows[m.mcpCursor], targets[n-1]
+	if err := toggleMCPClient(row.key, target.ID()); err != nil {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ Cannot save the client choice: %v", err))
+		return m
+	}
+	m.message = fmt.Sprintf("%s: %s toggled, press A to deploy", row.key, target.App())
+	return m
+}
+
+func (m Model) viewMCP() string {
+	targets := m.mcpTargets()
+	config := loadAppConfig()
+	rows := mcpServerRows(m.tools)
 
-	// Count tools with MCP servers
 	toolsWithMCP := 0
-	totalServers := 0
 	for _, tool := range m.tools {
 		if len(tool.MCPServers) > 0 {
 			toolsWithMCP++
-			totalServers += len(tool.MCPServers)
 		}
 	}
 
-	configStatus := fmt.Sprintf("Current MCP servers configured: %d", serverCount)
-	availableStatus := fmt.Sprintf("Available MCP servers: %d (from %d tools)", totalServers, toolsWithMCP)
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n\nMCP Server Configuration\n", titleStyle.Render("MCP Configuration"))
+	fmt.Fprintf(&b, "Available MCP servers: %d (from %d tools)\n", len(rows), toolsWithMCP)
+	if m.dryRun {
+		b.WriteString("Plan mode: A only shows the changes\n")
+	}
 
-	return fmt.Sprintf(`
-%s
+	width := len("Server")
+	for _, row := range rows {
+		if len(row.key) > width {
+			width = len(row.key)
+		}
+	}
+	fmt.Fprintf(&b, "\n  %-*s", width, "Server")
+	for i := range targets {
+		fmt.Fprintf(&b, "  %-3d", i+1)
+	}
+	b.WriteString("\n")
+	for i, row := range rows {
+		cursor := " "
+		if i == m.mcpCursor {
+			cursor = selectedStyle.Render("→")
+		}
+		fmt.Fprintf(&b, "%s %-*s", cursor, width, row.key)
+		chosen := config.mcpServerClients(row.key)
+		for _, target := range targets {
+			selected := containsString(chosen, target.ID())
+			deployed, present := m.mcpStates[target.ID()].servers[row.key]
+			cell := "[ ]"
+			if selected {
+				cell = "[x]"
+			}
+			if selected != present || (selected && !sameMCPEntry(deployed, row.entry)) {
+				cell += "*"
+			} else {
+				cell += " "
+			}
+			fmt.Fprintf(&b, " %s", cell)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n* A will add, update or remove the server there\n\nClients:\n")
 
-MCP Server Configuration
-%s
-%s
+	for i, target := range targets {
+		fmt.Fprintf(&b, "%d %-22s %s", i+1, target.App(), target.Path())
+		if target.ID() == "claude-desktop" {
+			if m.mcpConfigFrom == "detected" {
+				fmt.Fprintf(&b, " (detected for %s)", runtime.GOOS)
+			} else {
+				fmt.Fprintf(&b, " (from %s)", m.mcpConfigFrom)
+			}
+			if owner := mcpConfigOwner(target.Path()); owner == "" {
+				b.WriteString(", not a Claude Desktop config file")
+			} else if owner != target.App() {
+				fmt.Fprintf(&b, ", used by %s", owner)
+			}
+		}
 
-Config location: %s
+		state := m.mcpStates[target.ID()]
+		switch {
+		case state.err == nil:
+			fmt.Fprintf(&b, ": %d servers\n", len(state.servers))
+		case os.IsNotExist(state.err):
+			b.WriteString(": not created yet\n")
+		default:
+			fmt.Fprintf(&b, ": cannot be read: %v\n", state.err)
+		}
+	}
 
+	fmt.Fprintf(&b, `
 Options:
-%s A: Configure all available MCP servers
+%s ↑/↓: Select server • 1-%d: Toggle client for the server
+%s A: Deploy all servers to their selected clients
+%s B: Restore a backup of a client config
 %s Esc: Back to menu
 
-MCP servers enable AI tools to interact with local services
-and provide enhanced functionality within Claude Desktop.
-
 %s
 `,
-		titleStyle.Render("MCP Configuration"),
-		configStatus,
-		availableStatus,
-		m.mcpConfigPath,
+		selectedStyle.Render("→"), len(targets),
+		selectedStyle.Render("→"),
 		selectedStyle.Render("→"),
 		selectedStyle.Render("→"),
 		m.message,
 	)
-}
\ No newline at end of file
+	return b.String()
+}
+
+// loadMCPBackups refreshes the list on the backups screen
+func (m Model) loadMCPBackups() Model {
+	rows, err := mcpBackupRows(m.mcpTargets())
+	if err != nil {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ Cannot list backups: %v", err))
+	}
+	m.mcpBackups = rows
+	if m.backupCursor >= len(rows) {
+		m.backupCursor = max(len(rows)-1, 0)
+	}
+	return m
+}
+
+func restoreMCPBackup(row mcpBackupRow) tea.Cmd {
+	return func() tea.Msg {
+		return mcpRestoreMsg{path: row.target.Path(), err: restoreConfigBackup(row.target.Path(), row.backup)}
+	}
+}
+
+func (m Model) viewMCPBackups() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n\n", titleStyle.Render("MCP Config Backups"))
+	if len(m.mcpBackups) == 0 {
+		b.WriteString("No backups yet. A backup is made before every change to a client's config.\n")
+	}
+	client := ""
+	for i, row := range m.mcpBackups {
+		if row.target.ID() != client {
+			client = row.target.ID()
+			fmt.Fprintf(&b, "%s (%s)\n", row.target.App(), row.target.Path())
+		}
+		cursor := " "
+		if i == m.backupCursor {
+			cursor = selectedStyle.Render("→")
+		}
+		fmt.Fprintf(&b, "%s %s  %d bytes\n", cursor, row.backup.Time.Format("2006-01-02 15:04:05"), row.backup.Size)
+	}
+
+	fmt.Fprintf(&b, "\n%s ↑/↓: Select • Enter: Restore • Esc: Back\n\n%s\n", selectedStyle.Render("→"), m.message)
+	return b.String()
+}
@@ -0,0 +1,210 @@
+package src
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// mcpBackupRow is one backup of a client's config
+type mcpBackupRow struct {
+	target mcpTarget
+	backup configBackup
+}
+
+// mcpBackupRows lists the backups of every client's config, newest first
+// within each client
+func mcpBackupRows(targets []mcpTarget) ([]mcpBackupRow, error) {
+	var rows []mcpBackupRow
+	for _, target := range targets {
+		backups, err := listConfigBackups(target.Path())
+		if err != nil {
+			return nil, err
+		}
+		for _, backup := range backups {
+			rows = append(rows, mcpBackupRow{target: target, backup: backup})
+		}
+	}
+	return rows, nil
+}
+
+// writeMCPBackups prints the backups grouped by client and numbered as
+// "mcp restore" expects
+func writeMCPBackups(w io.Writer, rows []mcpBackupRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No MCP config backups yet")
+		return
+	}
+	client, n := "", 0
+	for _, row := range rows {
+		if row.target.ID() != client {
+			client, n = row.target.ID(), 0
+			fmt.Fprintf(w, "%s [%s] (%s):\n", row.target.App(), client, row.target.Path())
+		}
+		n++
+		fmt.Fprintf(w, "  %2d  %s  %6d bytes  %s\n", n, row.backup.Time.Format("2006-01-02 15:04:05"), row.backup.Size, row.backup.Path)
+	}
+}
+
+func runMCPCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ai-cli-manager mcp plan|backups|restore")
+		return 2
+	}
+
+	switch args[0] {
+	case "plan":
+		return runMCPPlan(args[1:])
+	case "backups":
+		fs := flag.NewFlagSet("mcp backups", flag.ContinueOnError)
+		client := fs.String("client", "", "only list the backups of this client")
+		format := fs.String("format", "text", "output format: text or json")
+		mcpConfig := fs.String("mcp-config", "", "Claude Desktop config file to use instead of the detected one")
+		if _, err := parseFlags(fs, args[1:]); err != nil {
+			return 2
+		}
+
+		targets := mcpTargets(resolveMCPConfig(*mcpConfig).path)
+		if *client != "" {
+			target, ok := findMCPTarget(targets, *client)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown MCP client: %s\n", *client)
+				return 2
+			}
+			targets = []mcpTarget{target}
+		}
+		rows, err := mcpBackupRows(targets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		if *format == "json" {
+			type backupJSON struct {
+				Client string `json:"client"`
+				File   string `json:"file"`
+				configBackup
+			}
+			out := []backupJSON{}
+			for _, row := range rows {
+				out = append(out, backupJSON{Client: row.target.ID(), File: row.target.Path(), configBackup: row.backup})
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+		writeMCPBackups(os.Stdout, rows)
+		return 0
+
+	case "restore":
+		fs := flag.NewFlagSet("mcp restore", flag.ContinueOnError)
+		mcpConfig := fs.String("mcp-config", "", "Claude Desktop config file to use instead of the detected one")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return 2
+		}
+		if len(positional) == 0 || len(positional) > 2 {
+			fmt.Fprintln(os.Stderr, "Usage: ai-cli-manager mcp restore <client> <n>, with n from \"mcp backups\"")
+			return 2
+		}
+
+		targets := mcpTargets(resolveMCPConfig(*mcpConfig).path)
+		target, ok := findMCPTarget(targets, positional[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown MCP client: %s\n", positional[0])
+			return 2
+		}
+		rows, err := mcpBackupRows([]mcpTarget{target})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(positional) == 1 {
+			writeMCPBackups(os.Stderr, rows)
+			fmt.Fprintf(os.Stderr, "Choose one: ai-cli-manager mcp restore %s <n>\n", target.ID())
+			return 2
+		}
+		n, err := strconv.Atoi(positional[1])
+		if err != nil || n < 1 || n > len(rows) {
+			fmt.Fprintf(os.Stderr, "No backup %s of %s; see \"ai-cli-manager mcp backups --client %s\"\n", positional[1], target.App(), target.ID())
+			return 2
+		}
+
+		backup := rows[n-1].backup
+		if err := restoreConfigBackup(target.Path(), backup); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Failed to restore %s: %v\n", target.Path(), err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "✓ Restored %s from the backup of %s\n", target.Path(), backup.Time.Format("2006-01-02 15:04:05"))
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown mcp command: %s\n", args[0])
+	return 2
+}
+
+// runMCPPlan prints what deploying the MCP servers of the named tools, or
+// of all tools, to their chosen clients would change, without writing
+func runMCPPlan(args []string) int {
+	fs := flag.NewFlagSet("mcp plan", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	mcpConfig := fs.String("mcp-config", "", "Claude Desktop config file to use instead of the detected one")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	tools, ok := loadCLICatalog()
+	if !ok {
+		return 1
+	}
+	if len(names) > 0 {
+		var selected []AITool
+		for _, name := range names {
+			i, ok := findTool(tools, name)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown tool: %s\n", name)
+				return 2
+			}
+			selected = append(selected, tools[i])
+		}
+		tools = selected
+	}
+
+	changes, err := planMCP(mcpTargets(resolveMCPConfig(*mcpConfig).path), loadAppConfig(), tools)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *format == "json" {
+		if changes == nil {
+			changes = []mcpChange{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	pending := pendingMCPChanges(changes)
+	if len(pending) == 0 {
+		fmt.Println("No MCP changes: every server is deployed as chosen")
+		return 0
+	}
+	writeMCPChanges(os.Stdout, "", pending)
+	if unchanged := len(changes) - len(pending); unchanged > 0 {
+		fmt.Printf("Unchanged entries: %d\n", unchanged)
+	}
+	return 0
+}
@@ -0,0 +1,323 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// mcpTarget is an MCP client's config file. Each adapter reads the servers
+// in it and merges changes in the client's own format, leaving the rest of
+// the file alone.
+type mcpTarget interface {
+	ID() string   // names the client in config.json, e.g. "cursor"
+	App() string  // names the client for the user, e.g. "Cursor"
+	Path() string // the config file
+	// Read returns the servers in the file, or an error satisfying
+	// os.IsNotExist when there is no file yet
+	Read() (map[string]MCPServerEntry, error)
+	// Write adds or replaces the servers in set and deletes those in
+	// remove, creating the file if needed
+	Write(set map[string]MCPServerEntry, remove []string) error
+}
+
+// defaultMCPClients receive a server that has no clients chosen in
+// config.json
+var defaultMCPClients = []string{"claude-desktop"}
+
+// mcpTargets returns the supported clients, in the order the MCP screen
+// numbers them. desktopPath is the Claude Desktop config in use.
+func mcpTargets(desktopPath string) []mcpTarget {
+	homeDir, _ := os.UserHomeDir()
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	projectDir, _ := os.Getwd()
+
+	return []mcpTarget{
+		jsonTarget{id: "claude-desktop", app: "Claude Desktop", path: desktopPath, key: "mcpServers"},
+		jsonTarget{id: "claude-code", app: "Claude Code", path: filepath.Join(homeDir, ".claude.json"), key: "mcpServers", stdio: true},
+		jsonTarget{id: "claude-code-project", app: "Claude Code (project)", path: filepath.Join(projectDir, ".mcp.json"), key: "mcpServers", stdio: true},
+		jsonTarget{id: "cursor", app: "Cursor", path: filepath.Join(homeDir, ".cursor", "mcp.json"), key: "mcpServers"},
+		jsonTarget{id: "vscode", app: "VS Code", path: filepath.Join(configDir, "Code", "User", "mcp.json"), key: "servers", stdio: true},
+		jsonTarget{id: "gemini", app: "Gemini CLI", path: filepath.Join(homeDir, ".gemini", "settings.json"), key: "mcpServers"},
+		codexTarget{path: codexConfigPath()},
+	}
+}
+
+// findMCPTarget returns the target with the given ID
+func findMCPTarget(targets []mcpTarget, id string) (mcpTarget, bool) {
+	for _, target := range targets {
+		if target.ID() == id {
+			return target, true
+		}
+	}
+	return nil, false
+}
+
+// mcpServerClients returns the IDs of the clients the server with key is
+// deployed to
+func (c appConfig) mcpServerClients(key string) []string {
+	if clients, ok := c.MCPClients[key]; ok {
+		return clients
+	}
+	return defaultMCPClients
+}
+
+// toggleMCPClient adds the client to the server's clients in config.json,
+// or removes it when it is already there
+func toggleMCPClient(key, id string) error {
+	config := loadAppConfig()
+	var clients []string
+	found := false
+	for _, client := range config.mcpServerClients(key) {
+		if client == id {
+			found = true
+			continue
+		}
+		clients = append(clients, client)
+	}
+	if !found {
+		clients = append(clients, id)
+	}
+	if clients == nil {
+		clients = []string{}
+	}
+
+	if config.MCPClients == nil {
+		config.MCPClients = make(map[string][]string)
+	}
+	config.MCPClients[key] = clients
+	return saveAppConfig(config)
+}
+
+// jsonTarget is a client that keeps its servers in one object of a JSON
+// file, keyed by server name
+type jsonTarget struct {
+	id, app, path string
+	key           string // member holding the servers, "mcpServers" or "servers"
+	stdio         bool   // entries carry "type": "stdio"
+}
+
+func (t jsonTarget) ID() string   { return t.id }
+func (t jsonTarget) App() string  { return t.app }
+func (t jsonTarget) Path() string { return t.path }
+
+// load returns the whole file and its servers
+func (t jsonTarget) load() (jsonObject, jsonObject, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc, servers jsonObject
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if raw, ok := doc.get(t.key); ok {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", t.key, err)
+		}
+	}
+	return doc, servers, nil
+}
+
+func (t jsonTarget) Read() (map[string]MCPServerEntry, error) {
+	_, raw, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make(map[string]MCPServerEntry, len(raw))
+	for _, member := range raw {
+		var entry MCPServerEntry
+		if err := json.Unmarshal(member.value, &entry); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.key, member.key, err)
+		}
+		servers[member.key] = entry
+	}
+	return servers, nil
+}
+
+// Write changes only the servers it is given, and in them only the fields
+// this tool manages. Everything else keeps its value and its place.
+func (t jsonTarget) Write(set map[string]MCPServerEntry, remove []string) error {
+	doc, servers, err := t.load()
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return refuseWrite(t.path, err)
+	}
+
+	for _, name := range remove {
+		servers.delete(name)
+	}
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		existing, _ := servers.get(name)
+		data, err := t.mergeEntry(existing, set[name])
+		if err != nil {
+			return err
+		}
+		servers.set(name, data)
+	}
+
+	data, err := encodeJSON(servers, "")
+	if err != nil {
+		return err
+	}
+	doc.set(t.key, data)
+
+	data, err = encodeJSON(doc, "  ")
+	if err != nil {
+		return err
+	}
+	return writeConfigFile(t.path, append(data, '\n'))
+}
+
+// refuseWrite explains why a config file that cannot be read is not
+// written: starting over would lose everything in it
+func refuseWrite(path string, err error) error {
+	return fmt.Errorf("cannot read %s, leaving it unchanged: %w", path, err)
+}
+
+// mergeEntry writes entry into the server's existing object, keeping the
+// fields it does not set
+func (t jsonTarget) mergeEntry(existing json.RawMessage, entry MCPServerEntry) (json.RawMessage, error) {
+	var server jsonObject
+	if existing != nil && json.Unmarshal(existing, &server) != nil {
+		server = nil // not an object, replace it
+	}
+
+	fields := []struct {
+		key   string
+		value interface{}
+		keep  bool
+	}{
+		{"type", "stdio", t.stdio},
+		{"command", entry.Command, true},
+		{"args", entry.Args, len(entry.Args) > 0},
+		{"env", entry.Env, len(entry.Env) > 0},
+	}
+	for _, field := range fields {
+		if !field.keep {
+			if field.key != "type" {
+				server.delete(field.key)
+			}
+			continue
+		}
+		data, err := encodeJSON(field.value, "")
+		if err != nil {
+			return nil, err
+		}
+		server.set(field.key, data)
+	}
+	return encodeJSON(server, "")
+}
+
+// encodeJSON marshals v without escaping <, > and &, so that values the
+// user wrote are written back as they were
+func encodeJSON(v interface{}, indent string) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// jsonObject is a JSON object that keeps its members in file order, so
+// that rewriting a config file changes nothing but what was meant to
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	*o = nil
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		o.set(tok.(string), value)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := encodeJSON(member.key, "")
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(member.value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (o jsonObject) get(key string) (json.RawMessage, bool) {
+	for _, member := range o {
+		if member.key == key {
+			return member.value, true
+		}
+	}
+	return nil, false
+}
+
+// set replaces the value of key where it is, or adds key at the end
+func (o *jsonObject) set(key string, value json.RawMessage) {
+	for i := range *o {
+		if (*o)[i].key == key {
+			(*o)[i].value = value
+			return
+		}
+	}
+	*o = append(*o, jsonMember{key: key, value: value})
+}
+
+func (o *jsonObject) delete(key string) {
+	for i := range *o {
+		if (*o)[i].key == key {
+			*o = append((*o)[:i], (*o)[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const jsonTargetFixture = `{
+  "zeta": true,
+  "numbers": {
+    "big": 123456789012345678901234567890,
+    "float": 1.50,
+    "exp": 1e400
+  },
+  "mcpServers": {
+    "other": {
+      "url": "https://example.com/?a=1&b=<2>",
+      "command": "keep"
+    },
+    "target": {
+      "timeout": 60000,
+      "env": {
+        "OLD": "1"
+      },
+      "command": "old",
+      "disabled": false,
+      "args": [
+        "a"
+      ]
+    }
+  },
+  "alpha": "<b>&amp;</b>"
+}
+`
+
+func writeJSONTarget(t *testing.T, stdio bool, text string, mode os.FileMode) jsonTarget {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir()) // backups go under the home directory
+	target := jsonTarget{id: "test", app: "Test", path: filepath.Join(t.TempDir(), "mcp.json"), key: "mcpServers", stdio: stdio}
+	if err := os.WriteFile(target.path, []byte(text), mode); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile is subject to the umask
+	if err := os.Chmod(target.path, mode); err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func TestJSONTargetWriteIsLossless(t *testing.T) {
+	target := writeJSONTarget(t, false, jsonTargetFixture, 0600)
+	err := target.Write(map[string]MCPServerEntry{
+		"target": {Command: "new", Args: []string{"x", "<&>"}, Env: map[string]string{"NEW": "2"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "zeta": true,
+  "numbers": {
+    "big": 123456789012345678901234567890,
+    "float": 1.50,
+    "exp": 1e400
+  },
+  "mcpServers": {
+    "other": {
+      "url": "https://example.com/?a=1&b=<2>",
+      "command": "keep"
+    },
+    "target": {
+      "timeout": 60000,
+      "env": {
+        "NEW": "2"
+      },
+      "command": "new",
+      "disabled": false,
+      "args": [
+        "x",
+        "<&>"
+      ]
+    }
+  },
+  "alpha": "<b>&amp;</b>"
+}
+`
+	data, err := os.ReadFile(target.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("config after Write:\n%s\nwant:\n%s", data, want)
+	}
+
+	info, err := os.Stat(target.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("file mode is %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestJSONTargetWriteAddsAndRemoves(t *testing.T) {
+	target := writeJSONTarget(t, true, jsonTargetFixture, 0644)
+	err := target.Write(map[string]MCPServerEntry{
+		"added": {Command: "srv"},
+	}, []string{"target"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "zeta": true,
+  "numbers": {
+    "big": 123456789012345678901234567890,
+    "float": 1.50,
+    "exp": 1e400
+  },
+  "mcpServers": {
+    "other": {
+      "url": "https://example.com/?a=1&b=<2>",
+      "command": "keep"
+    },
+    "added": {
+      "type": "stdio",
+      "command": "srv"
+    }
+  },
+  "alpha": "<b>&amp;</b>"
+}
+`
+	data, err := os.ReadFile(target.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("config after Write:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestJSONTargetDropsEmptyManagedFields(t *testing.T) {
+	target := writeJSONTarget(t, false, jsonTargetFixture, 0644)
+	if err := target.Write(map[string]MCPServerEntry{"target": {Command: "old"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, err := target.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := servers["target"]; got.Command != "old" || got.Args != nil || got.Env != nil {
+		t.Errorf("target = %#v, want only its command", got)
+	}
+	doc, raw, err := target.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var server jsonObject
+	existing, _ := raw.get("target")
+	if err := server.UnmarshalJSON(existing); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, member := range server {
+		keys = append(keys, member.key)
+	}
+	if got := strings.Join(keys, ","); got != "timeout,command,disabled" {
+		t.Errorf("target keys = %s, want timeout,command,disabled", got)
+	}
+	if _, ok := doc.get("alpha"); !ok {
+		t.Error("unknown top-level key was lost")
+	}
+}
+
+func TestJSONTargetRefusesInvalidJSON(t *testing.T) {
+	text := `{"mcpServers": {"a": {"command": "x"}},}`
+	target := writeJSONTarget(t, false, text, 0644)
+	if err := target.Write(map[string]MCPServerEntry{"b": {Command: "y"}}, nil); err == nil {
+		t.Fatal("Write accepted invalid JSON")
+	}
+	data, err := os.ReadFile(target.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != text {
+		t.Errorf("refused Write changed the file: %s", data)
+	}
+}
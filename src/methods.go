@@ -0,0 +1,336 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// InstallMethod is one way of installing a tool. A tool lists its methods in
+// order of preference and the first one usable on the host is picked.
+type InstallMethod struct {
+	Type     string   `json:"type"`               // npm, pip, pipx, uv, go, cargo, brew, apt, binary or script
+	Package  string   `json:"package,omitempty"`  // package, module or formula name
+	URL      string   `json:"url,omitempty"`      // download URL for binary and script methods
+	Args     []string `json:"args,omitempty"`     // extra arguments for the package manager or script
+	OS       []string `json:"os,omitempty"`       // limit to these runtime.GOOS values
+	Arch     []string `json:"arch,omitempty"`     // limit to these runtime.GOARCH values
+	Requires []string `json:"requires,omitempty"` // other commands that must be on PATH
+
+	// Binary downloads and scripts
+	SHA256 string `json:"sha256,omitempty"` // checksum of the file at URL; pre-approves a script
+
+	// Scripts only
+	Shell string `json:"shell,omitempty"` // interpreter the script is written for, defaults to sh
+
+	// Binary downloads only
+	Assets  []BinaryAsset `json:"assets,omitempty"`  // per-platform URLs, used instead of URL
+	Archive string        `json:"archive,omitempty"` // "tar.gz", "zip" or "none"; guessed from the URL if unset
+	Binary  string        `json:"binary,omitempty"`  // executable inside the archive, defaults to cli_command
+}
+
+// installMethodTypes maps each method type to the package managers that can
+// run it, in order of preference. Binary downloads need nothing external,
+// and scripts need their shell (see managers).
+var installMethodTypes = map[string][]string{
+	"npm":    {"npm"},
+	"pip":    {"pip", "pip3"},
+	"pipx":   {"pipx"},
+	"uv":     {"uv"},
+	"go":     {"go"},
+	"cargo":  {"cargo"},
+	"brew":   {"brew"},
+	"apt":    {"apt-get"},
+	"binary": {},
+	"script": {"sh"},
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// manager returns the package manager binary found on PATH for this method
+func (im InstallMethod) manager() (string, error) {
+	managers, ok := im.managers()
+	if !ok {
+		return "", fmt.Errorf("unknown install method %q", im.Type)
+	}
+	if len(managers) == 0 {
+		return "", nil
+	}
+	for _, name := range managers {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found", strings.Join(managers, "/"))
+}
+
+// managers returns the commands that can run the method, in order of
+// preference
+func (im InstallMethod) managers() ([]string, bool) {
+	if im.Type == "script" {
+		return []string{im.shell()}, true
+	}
+	managers, ok := installMethodTypes[im.Type]
+	return managers, ok
+}
+
+// shell returns the interpreter a script method runs with
+func (im InstallMethod) shell() string {
+	if im.Shell == "" {
+		return "sh"
+	}
+	return im.Shell
+}
+
+// supportsHost reports whether the method's os and arch include this host
+func (im InstallMethod) supportsHost() error {
+	if len(im.OS) > 0 && !containsString(im.OS, runtime.GOOS) {
+		return fmt.Errorf("only for %s", strings.Join(im.OS, ", "))
+	}
+	if len(im.Arch) > 0 && !containsString(im.Arch, runtime.GOARCH) {
+		return fmt.Errorf("only for %s", strings.Join(im.Arch, ", "))
+	}
+	return nil
+}
+
+// available reports whether the method can run on this host, and why not
+func (im InstallMethod) available() error {
+	if err := im.supportsHost(); err != nil {
+		return err
+	}
+	if _, err := im.manager(); err != nil {
+		return err
+	}
+	if _, err := im.forHost(); err != nil {
+		return err
+	}
+	for _, name := range im.Requires {
+		if _, err := exec.LookPath(name); err != nil {
+			return fmt.Errorf("%s not found", name)
+		}
+	}
+	return nil
+}
+
+// command returns the argv that installs the package with this method.
+// Binary and script methods download first and have no single command.
+func (im InstallMethod) command() ([]string, error) {
+	manager, err := im.manager()
+	if err != nil {
+		return nil, err
+	}
+
+	var argv []string
+	switch im.Type {
+	case "npm":
+		argv = []string{manager, "install", "-g"}
+	case "pip", "pipx", "cargo", "brew":
+		argv = []string{manager, "install"}
+	case "uv":
+		argv = []string{manager, "tool", "install"}
+	case "go":
+		pkg := im.Package
+		if !strings.Contains(pkg, "@") {
+			pkg += "@latest"
+		}
+		return append(append([]string{manager, "install"}, im.Args...), pkg), nil
+	case "apt":
+		argv = withSudo([]string{manager, "install", "-y"})
+	default:
+		return nil, fmt.Errorf("%s method has no install command", im.Type)
+	}
+
+	return append(append(argv, im.Args...), im.Package), nil
+}
+
+var (
+	// exactVersion matches a plain version such as 1.2.3, v1.2, 1.2.3-rc.1
+	// or the Debian 1:2.3-1ubuntu1, but not a wildcard like 1.x
+	exactVersion = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+)*([-+~:.]?[0-9A-WYZa-wyz][0-9A-Za-z.+~:-]*)?$`)
+	// pipSpecifiers matches PEP 440 version specifiers, such as >=1.2,<2.
+	// npm-style ranges like ^1.2 or 1.x are not among them.
+	pipSpecifiers = regexp.MustCompile(`^\s*(~=|===?|!=|<=?|>=?)\s*[0-9A-Za-z.*+!-]+(\s*,\s*(~=|===?|!=|<=?|>=?)\s*[0-9A-Za-z.*+!-]+)*\s*$`)
+)
+
+// withSudo runs argv through sudo unless this process is root. -n makes sudo
+// fail instead of prompting: commands run in their own process group, where
+// a password prompt would stop waiting for the terminal.
+func withSudo(argv []string) []string {
+	if os.Geteuid() == 0 {
+		return argv
+	}
+	return append([]string{"sudo", "-n"}, argv...)
+}
+
+// checkSudo fails with advice when sudo would need a password
+func checkSudo(ctx context.Context) error {
+	if exec.CommandContext(ctx, "sudo", "-n", "true").Run() != nil {
+		return fmt.Errorf("sudo needs a password, which cannot be asked for here; run `sudo -v` in this terminal first, then try again")
+	}
+	return nil
+}
+
+// pinned returns a copy of the method that installs the given version, or
+// version constraint, in the package manager's own syntax. Binary and script
+// URLs may contain a {version} placeholder.
+func (im InstallMethod) pinned(version string) (InstallMethod, error) {
+	if version == "" {
+		return im, nil
+	}
+	// An exact version may be written with or without a leading "v"
+	exact := exactVersion.MatchString(version)
+	if exact {
+		version = strings.TrimPrefix(version, "v")
+	}
+
+	switch im.Type {
+	case "npm":
+		im.Package += "@" + version
+	case "pip", "pipx", "uv":
+		if exact {
+			version = "==" + version
+		} else if !pipSpecifiers.MatchString(version) {
+			return im, fmt.Errorf("%s needs an exact version or a specifier such as >=1.2,<2, got %q", im.Type, version)
+		}
+		im.Package += version
+	case "go":
+		if !exact {
+			return im, fmt.Errorf("go install needs an exact version, got %q", version)
+		}
+		im.Package = strings.SplitN(im.Package, "@", 2)[0] + "@v" + version
+	case "cargo":
+		im.Args = append(append([]string{}, im.Args...), "--version", version)
+	case "apt":
+		if !exact {
+			return im, fmt.Errorf("apt needs an exact version, got %q", version)
+		}
+		im.Package += "=" + version
+	case "binary", "script":
+		if !strings.Contains(im.URL, "{version}") {
+			return im, fmt.Errorf("%s URL has no {version} placeholder to pin %q", im.Type, version)
+		}
+		im.URL = strings.ReplaceAll(im.URL, "{version}", strings.TrimPrefix(version, "v"))
+	default:
+		return im, fmt.Errorf("%s cannot install a specific version", im.Type)
+	}
+	return im, nil
+}
+
+func (im InstallMethod) String() string {
+	switch im.Type {
+	case "binary", "script":
+		return fmt.Sprintf("%s %s", im.Type, im.URL)
+	}
+	return fmt.Sprintf("%s %s", im.Type, im.Package)
+}
+
+// selectInstallMethod picks the first method usable on this host
+func selectInstallMethod(tool AITool) (InstallMethod, error) {
+	var reasons []string
+	for _, method := range tool.InstallMethods {
+		err := method.available()
+		if err == nil {
+			return method.forHost()
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", method, err))
+	}
+	return InstallMethod{}, fmt.Errorf("no usable install method (%s)", strings.Join(reasons, "; "))
+}
+
+// managedBinDir is where binary downloads and the shims of managed installs
+// are placed
+func managedBinDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "bin")
+}
+
+// runInstallMethod installs tool with method, into prefix when it is set.
+// review approves scripts that have no sha256 in the catalog.
+func runInstallMethod(ctx context.Context, tool AITool, method InstallMethod, prefix string, out io.Writer, review scriptReviewer) error {
+	if prefix != "" {
+		return installManaged(ctx, tool, method, prefix, false, out)
+	}
+
+	switch method.Type {
+	case "binary":
+		return installBinary(ctx, tool, method, managedBinDir(), out)
+	case "script":
+		return runScript(ctx, tool, method, out, review)
+	}
+
+	argv, err := method.command()
+	if err != nil {
+		return err
+	}
+	return runCommand(ctx, out, argv)
+}
+
+func download(ctx context.Context, url string, dst io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(dst, resp.Body)
+	return err
+}
+
+// runScript downloads a script and runs it with sh once it is approved,
+// like the installers of cloned repositories
+func runScript(ctx context.Context, tool AITool, method InstallMethod, out io.Writer, review scriptReviewer) error {
+	f, err := os.CreateTemp("", "ai-cli-install-*.sh")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	fmt.Fprintf(out, "Downloading %s\n", method.URL)
+	var content bytes.Buffer
+	err = download(ctx, method.URL, io.MultiWriter(f, &content))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	command := strings.Join(append([]string{method.shell(), path.Base(method.URL)}, method.Args...), " ")
+	if err := approveScript(ctx, scriptReview{
+		tool:     tool,
+		source:   method.URL,
+		file:     method.URL,
+		command:  command,
+		content:  content.Bytes(),
+		sha256:   sha256Hex(content.Bytes()),
+		approved: method.SHA256,
+		field:    "the method's sha256",
+	}, review, out); err != nil {
+		return err
+	}
+
+	return runInDir(ctx, out, "", method.shell(), append([]string{f.Name()}, method.Args...)...)
+}
@@ -0,0 +1,49 @@
+package src
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPinned(t *testing.T) {
+	tests := []struct {
+		method  InstallMethod
+		version string
+		want    string // Package, or the error's text
+		args    []string
+	}{
+		{InstallMethod{Type: "npm", Package: "pkg"}, "1.2.3", "pkg@1.2.3", nil},
+		{InstallMethod{Type: "npm", Package: "pkg"}, "v1.2.3", "pkg@1.2.3", nil},
+		{InstallMethod{Type: "npm", Package: "pkg"}, "^1.2", "pkg@^1.2", nil},
+		{InstallMethod{Type: "pip", Package: "pkg"}, "1.2.3", "pkg==1.2.3", nil},
+		{InstallMethod{Type: "pip", Package: "pkg"}, "v1.2.3", "pkg==1.2.3", nil},
+		{InstallMethod{Type: "pip", Package: "pkg"}, "2024.1.0.post1", "pkg==2024.1.0.post1", nil},
+		{InstallMethod{Type: "pip", Package: "pkg"}, ">=1.2,<2", "pkg>=1.2,<2", nil},
+		{InstallMethod{Type: "pipx", Package: "pkg"}, "~=1.2", "pkg~=1.2", nil},
+		{InstallMethod{Type: "pip", Package: "pkg"}, "^1.2", "error: pip needs an exact version or a specifier", nil},
+		{InstallMethod{Type: "uv", Package: "pkg"}, "1.x", "error: uv needs an exact version or a specifier", nil},
+		{InstallMethod{Type: "go", Package: "example.com/cmd@latest"}, "1.2.3", "example.com/cmd@v1.2.3", nil},
+		{InstallMethod{Type: "go", Package: "example.com/cmd"}, "v1.2.3", "example.com/cmd@v1.2.3", nil},
+		{InstallMethod{Type: "go", Package: "example.com/cmd"}, "^1.2", "error: go install needs an exact version", nil},
+		{InstallMethod{Type: "apt", Package: "pkg"}, "1:2.3-1ubuntu1", "pkg=1:2.3-1ubuntu1", nil},
+		{InstallMethod{Type: "apt", Package: "pkg"}, ">=2", "error: apt needs an exact version", nil},
+		{InstallMethod{Type: "cargo", Package: "pkg"}, "v1.2.3", "pkg", []string{"--version", "1.2.3"}},
+	}
+	for _, tt := range tests {
+		got, err := tt.method.pinned(tt.version)
+		if strings.HasPrefix(tt.want, "error: ") {
+			if err == nil || !strings.Contains(err.Error(), strings.TrimPrefix(tt.want, "error: ")) {
+				t.Errorf("%s %q: got %v, %v; want an error like %q", tt.method.Type, tt.version, got.Package, err, tt.want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.method.Type, tt.version, err)
+			continue
+		}
+		if got.Package != tt.want || !reflect.DeepEqual(got.Args, tt.args) {
+			t.Errorf("%s %q: got %s %v, want %s %v", tt.method.Type, tt.version, got.Package, got.Args, tt.want, tt.args)
+		}
+	}
+}
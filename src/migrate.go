@@ -0,0 +1,269 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currentSchemaVersion is the catalog format written by this version.
+//
+// Version history:
+//
+//	1: bare JSON array of tools
+//	2: {"schema_version": 2, "tools": [...]}
+//	3: install_cmd converted to typed install_methods where recognized
+const currentSchemaVersion = 3
+
+// catalogDocument is the on-disk catalog format
+type catalogDocument struct {
+	SchemaVersion int      `json:"schema_version"`
+	Tools         []AITool `json:"tools"`
+}
+
+// catalogMigrations upgrade a catalog from the version used as key to the
+// next one. Every migration works on raw JSON so unknown fields survive.
+var catalogMigrations = map[int]func([]byte) ([]byte, error){
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+}
+
+func migrateV1ToV2(data []byte) ([]byte, error) {
+	var tools []json.RawMessage
+	if err := json.Unmarshal(data, &tools); err != nil {
+		return nil, err
+	}
+	if tools == nil {
+		tools = []json.RawMessage{}
+	}
+	return json.Marshal(struct {
+		SchemaVersion int               `json:"schema_version"`
+		Tools         []json.RawMessage `json:"tools"`
+	}{2, tools})
+}
+
+func migrateV2ToV3(data []byte) ([]byte, error) {
+	return rewriteTools(data, 3, func(fields []jsonField) ([]jsonField, error) {
+		for i, field := range fields {
+			if field.key != "install_cmd" {
+				continue
+			}
+			var cmd string
+			if err := json.Unmarshal(field.value, &cmd); err != nil {
+				return nil, err
+			}
+			method, ok := parseInstallCmd(cmd)
+			if !ok {
+				return fields, nil
+			}
+			value, err := json.Marshal([]InstallMethod{method})
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = jsonField{key: "install_methods", value: value}
+		}
+		return fields, nil
+	})
+}
+
+// parseInstallCmd recognizes the common single-line install commands
+func parseInstallCmd(cmd string) (InstallMethod, bool) {
+	parts := strings.Fields(cmd)
+	if len(parts) < 3 {
+		return InstallMethod{}, false
+	}
+
+	if parts[0] == "curl" && len(parts) >= 4 && parts[len(parts)-2] == "|" {
+		shell := parts[len(parts)-1]
+		for _, part := range parts[1 : len(parts)-2] {
+			if (shell == "sh" || shell == "bash") && strings.HasPrefix(part, "http") {
+				method := InstallMethod{Type: "script", URL: part}
+				if shell == "bash" {
+					method.Shell = shell
+				}
+				return method, true
+			}
+		}
+		return InstallMethod{}, false
+	}
+
+	manager, verb, rest := parts[0], parts[1], parts[2:]
+	var method InstallMethod
+	switch {
+	case manager == "npm" && (verb == "install" || verb == "i"):
+		method.Type = "npm"
+	case (manager == "pip" || manager == "pip3") && verb == "install":
+		method.Type = "pip"
+	case manager == "pipx" && verb == "install":
+		method.Type = "pipx"
+	case manager == "go" && verb == "install":
+		method.Type = "go"
+	case manager == "cargo" && verb == "install":
+		method.Type = "cargo"
+	case manager == "brew" && verb == "install":
+		method.Type = "brew"
+	default:
+		return InstallMethod{}, false
+	}
+
+	for _, part := range rest {
+		switch {
+		case method.Type == "npm" && (part == "-g" || part == "--global"):
+		case strings.HasPrefix(part, "-"):
+			method.Args = append(method.Args, part)
+		case method.Package == "":
+			method.Package = part
+		default:
+			// More than one package cannot be expressed as a single method
+			return InstallMethod{}, false
+		}
+	}
+	return method, method.Package != ""
+}
+
+// rewriteTools rebuilds a catalog document with a new schema version,
+// passing every tool's fields through rewrite. Key order is preserved.
+func rewriteTools(data []byte, version int, rewrite func([]jsonField) ([]jsonField, error)) ([]byte, error) {
+	fields, err := scanObject(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, field := range fields {
+		switch field.key {
+		case "schema_version":
+			fields[i].value = json.RawMessage(strconv.Itoa(version))
+		case "tools":
+			elements, err := scanArray(field.value, 0)
+			if err != nil {
+				return nil, err
+			}
+			var tools bytes.Buffer
+			tools.WriteByte('[')
+			for j, element := range elements {
+				toolFields, err := scanObject(element.value, 0)
+				if err != nil {
+					return nil, err
+				}
+				if toolFields, err = rewrite(toolFields); err != nil {
+					return nil, err
+				}
+				if j > 0 {
+					tools.WriteByte(',')
+				}
+				tools.Write(encodeObject(toolFields))
+			}
+			tools.WriteByte(']')
+			fields[i].value = tools.Bytes()
+		}
+	}
+
+	return encodeObject(fields), nil
+}
+
+func encodeObject(fields []jsonField) []byte {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.key)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(field.value)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+// catalogSchemaVersion detects the format of a catalog file
+func catalogSchemaVersion(data []byte) (int, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return 1, nil
+	}
+
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(trimmed, &header); err != nil {
+		return 0, err
+	}
+	if header.SchemaVersion == 0 {
+		return 0, fmt.Errorf("missing schema_version")
+	}
+	return header.SchemaVersion, nil
+}
+
+// migrateCatalog upgrades catalog data to the current schema version and
+// reports the version it started from.
+func migrateCatalog(data []byte) ([]byte, int, error) {
+	from, err := catalogSchemaVersion(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from > currentSchemaVersion {
+		return nil, from, fmt.Errorf("schema_version %d is newer than the supported version %d", from, currentSchemaVersion)
+	}
+
+	for version := from; version < currentSchemaVersion; version++ {
+		migrate, ok := catalogMigrations[version]
+		if !ok {
+			return nil, from, fmt.Errorf("no migration from schema_version %d", version)
+		}
+		if data, err = migrate(data); err != nil {
+			return nil, from, fmt.Errorf("migrating from schema_version %d: %w", version, err)
+		}
+	}
+
+	return data, from, nil
+}
+
+// migrateCatalogFile upgrades a catalog file on disk, keeping a backup of the
+// original next to it. Files that cannot be migrated are returned unchanged so
+// that validation can report what is wrong with them.
+func migrateCatalogFile(path string, data []byte) []byte {
+	migrated, from, err := migrateCatalog(data)
+	if err != nil || from == currentSchemaVersion {
+		return data
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, migrated, "", "  "); err != nil {
+		return data
+	}
+	out.WriteString("\n")
+
+	backup := fmt.Sprintf("%s.v%d.%s.bak", path, from, time.Now().Format("20060102T150405"))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return out.Bytes()
+	}
+	os.WriteFile(path, out.Bytes(), 0644)
+	return out.Bytes()
+}
+
+// decodeCatalog parses catalog data of any supported version
+func decodeCatalog(data []byte) ([]AITool, error) {
+	migrated, _, err := migrateCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+	var doc catalogDocument
+	if err := json.Unmarshal(migrated, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Tools, nil
+}
+
+// encodeCatalog renders tools in the current catalog format
+func encodeCatalog(tools []AITool) ([]byte, error) {
+	if tools == nil {
+		tools = []AITool{}
+	}
+	return json.MarshalIndent(catalogDocument{SchemaVersion: currentSchemaVersion, Tools: tools}, "", "  ")
+}
@@ -0,0 +1,180 @@
+package src
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// jsonValue decodes data for comparisons that ignore formatting
+func jsonValue(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return v
+}
+
+func TestMigrateCatalog(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		from int
+		want string
+	}{
+		{
+			name: "v1 bare array",
+			in: `[
+  {"name": "A", "cli_command": "a", "install_cmd": "npm install -g a-cli", "custom": 1},
+  {"name": "B", "cli_command": "b", "install_cmd": "make install"}
+]`,
+			from: 1,
+			want: `{"schema_version": 3, "tools": [
+  {"name": "A", "cli_command": "a", "install_methods": [{"type": "npm", "package": "a-cli"}], "custom": 1},
+  {"name": "B", "cli_command": "b", "install_cmd": "make install"}
+]}`,
+		},
+		{
+			name: "empty v1 array",
+			in:   `[]`,
+			from: 1,
+			want: `{"schema_version": 3, "tools": []}`,
+		},
+		{
+			name: "v2 document",
+			in: `{"schema_version": 2, "extra": true, "tools": [
+  {"name": "C", "install_cmd": "curl -fsSL https://example.com/install.sh | bash"}
+]}`,
+			from: 2,
+			want: `{"schema_version": 3, "extra": true, "tools": [
+  {"name": "C", "install_methods": [{"type": "script", "url": "https://example.com/install.sh", "shell": "bash"}]}
+]}`,
+		},
+		{
+			name: "current version",
+			in:   `{"schema_version": 3, "tools": [{"name": "D", "install_cmd": "npm install -g d"}]}`,
+			from: 3,
+			want: `{"schema_version": 3, "tools": [{"name": "D", "install_cmd": "npm install -g d"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, from, err := migrateCatalog([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != tt.from {
+				t.Errorf("migrated from version %d, want %d", from, tt.from)
+			}
+			if !reflect.DeepEqual(jsonValue(t, got), jsonValue(t, []byte(tt.want))) {
+				t.Errorf("migrated to\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateCatalogErrors(t *testing.T) {
+	for _, in := range []string{
+		`{"tools": []}`,
+		`{"schema_version": 99, "tools": []}`,
+		`not json`,
+	} {
+		if _, _, err := migrateCatalog([]byte(in)); err == nil {
+			t.Errorf("migrateCatalog(%s) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseInstallCmd(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want InstallMethod
+		ok   bool
+	}{
+		{"npm install -g @scope/cli", InstallMethod{Type: "npm", Package: "@scope/cli"}, true},
+		{"npm i --global cli", InstallMethod{Type: "npm", Package: "cli"}, true},
+		{"pip install --user tool", InstallMethod{Type: "pip", Package: "tool", Args: []string{"--user"}}, true},
+		{"pip3 install tool", InstallMethod{Type: "pip", Package: "tool"}, true},
+		{"pipx install tool", InstallMethod{Type: "pipx", Package: "tool"}, true},
+		{"go install example.com/tool@latest", InstallMethod{Type: "go", Package: "example.com/tool@latest"}, true},
+		{"cargo install tool", InstallMethod{Type: "cargo", Package: "tool"}, true},
+		{"brew install --cask lm-studio", InstallMethod{Type: "brew", Package: "lm-studio", Args: []string{"--cask"}}, true},
+		{"curl -fsSL https://example.com/install.sh | sh", InstallMethod{Type: "script", URL: "https://example.com/install.sh"}, true},
+		{"curl -fsSL https://example.com/install.sh | bash", InstallMethod{Type: "script", URL: "https://example.com/install.sh", Shell: "bash"}, true},
+		{"curl -fsSL https://example.com/install.sh | python3", InstallMethod{}, false},
+		{"curl -fsSL https://example.com/install.sh", InstallMethod{}, false},
+		{"npm install -g a b", InstallMethod{}, false},
+		{"npm install -g", InstallMethod{}, false},
+		{"make install", InstallMethod{}, false},
+		{"apt-get install tool", InstallMethod{}, false},
+		{"", InstallMethod{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseInstallCmd(tt.cmd)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseInstallCmd(%q) = %+v, %v; want %+v, %v", tt.cmd, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMigrateCatalogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.json")
+	original := []byte(`[{"name": "A", "cli_command": "a", "install_cmd": "pipx install a"}]`)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated := migrateCatalogFile(path, original)
+
+	onDisk, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(onDisk) != string(migrated) {
+		t.Errorf("file holds\n%s\nbut the migration returned\n%s", onDisk, migrated)
+	}
+	if version, err := catalogSchemaVersion(onDisk); err != nil || version != currentSchemaVersion {
+		t.Errorf("file is at version %d (%v), want %d", version, err, currentSchemaVersion)
+	}
+
+	backups, err := filepath.Glob(path + ".v1.*.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want one .v1.*.bak", backups)
+	}
+	backup, err := os.ReadFile(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("backup holds %s, want the original %s", backup, original)
+	}
+
+	// Migrating a current file again changes nothing
+	again := migrateCatalogFile(path, onDisk)
+	if string(again) != string(onDisk) {
+		t.Error("migrating a current file changed it")
+	}
+	if backups, _ := filepath.Glob(path + ".v*.bak"); len(backups) != 1 {
+		t.Errorf("backups = %v after the second run, want only the first", backups)
+	}
+}
+
+func TestMigrateCatalogFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.json")
+	data := []byte(`[{"name": `)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := migrateCatalogFile(path, data); string(got) != string(data) {
+		t.Errorf("invalid file migrated to %s", got)
+	}
+	if backups, _ := filepath.Glob(path + ".v*.bak"); len(backups) != 0 {
+		t.Errorf("invalid file was backed up: %v", backups)
+	}
+}
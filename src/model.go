@@ -1,25 +1,39 @@
 package src
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type AITool struct {
-	Name        string            `json:"name"`
-	CLICommand  string            `json:"cli_command"`
-	InstallCmd  string            `json:"install_cmd"`
-	CheckCmd    string            `json:"check_cmd"`
-	Description string            `json:"description"`
-	GitHubRepo  string            `json:"github_repo,omitempty"`
-	MCPServers  []MCPServerConfig `json:"mcp_servers,omitempty"`
-	Config      map[string]string `json:"config,omitempty"`
-	Installed   bool              `json:"-"`
+	Name           string            `json:"name"`
+	CLICommand     string            `json:"cli_command"`
+	InstallCmd     string            `json:"install_cmd,omitempty"` // legacy single command, used when no method applies
+	InstallMethods []InstallMethod   `json:"install_methods,omitempty"`
+	CheckCmd       string            `json:"check_cmd"`
+	VersionRegex   string            `json:"version_regex,omitempty"` // extracts the version from check_cmd output
+	Constraint     string            `json:"version,omitempty"`       // version to install, e.g. "1.2.3" or ">=1.2,<2"
+	Description    string            `json:"description"`
+	GitHubRepo     string            `json:"github_repo,omitempty"`
+	GitHubRef      string            `json:"github_ref,omitempty"`    // branch, tag or commit to check out
+	GitHubCommit   string            `json:"github_commit,omitempty"` // full SHA the checkout must resolve to
+	ScriptSHA256   string            `json:"script_sha256,omitempty"` // pre-approved hash of the repository's installer
+	MCPServers     []MCPServerConfig `json:"mcp_servers,omitempty"`
+	Prerequisites  []Prerequisite    `json:"prerequisites,omitempty"` // runtimes and catalog tools needed first
+	Timeout        string            `json:"timeout,omitempty"`       // install timeout, overrides install_timeout in config.json
+	Config         map[string]string `json:"config,omitempty"`
+	Disabled       bool              `json:"disabled,omitempty"`
+	Installed      bool              `json:"-"`
+	Version        string            `json:"-"`
+	Latest         string            `json:"-"` // newest version known to the package manager
+	Source         string            `json:"-"` // catalog layer the tool came from
+	Missing        []string          `json:"-"` // unmet prerequisites of a tool that is not installed
 }
 
 type MCPServerConfig struct {
@@ -34,24 +48,71 @@ type Model struct {
 	tools          []AITool
 	table          table.Model
 	selected       int
-	mode           string // "menu", "table", "installing", "config", "mcp"
+	mode           string // "menu", "table", "installing", "log", "review", "config", "mcp", "backups", "errors", "confirm"
 	message        string
 	installing     bool
+	poolAction     string // what the install pool runs: "install", "upgrade" or "uninstall"
 	installAllMode bool
 	githubUser     string
 	githubRepo     string
 	configSynced   bool
 	mcpConfigPath  string
+	mcpConfigFrom  string                    // where mcpConfigPath came from, see resolveMCPConfig
+	mcpCursor      int                       // selected server on the MCP screen
+	mcpStates      map[string]mcpTargetState // client configs as shown on the MCP screen
+	mcpBackups     []mcpBackupRow            // backups listed on the backups screen
+	backupCursor   int
+	issues         []validationIssue
+	confirm        *confirmation
+	lock           *lockFile
+	progress       map[string]installEvent // per-tool state of the running install pool
+	cancelInstall  context.CancelFunc      // stops the running install pool
+	quitting       bool                    // quit once the install pool has stopped
+	logView        viewport.Model
+	logLines       []string
+	logTitle       string // title and header of the text shown in "log" mode
+	logHeader      string
+	logBack        string         // mode to return to from "log" mode
+	reviews        []installEvent // installers waiting for approval
+	dryRun         bool           // show plans instead of installing or writing MCP config
 }
 
-type installMsg struct {
-	tool    AITool
-	success bool
-	err     error
+// confirmation is a yes/no question shown before a destructive action
+type confirmation struct {
+	prompt string
+	onYes  tea.Cmd
+	back   string // mode to return to
+}
+
+type installProgressMsg struct {
+	event  installEvent
+	events chan installEvent
+}
+
+type installPoolDoneMsg struct{}
+
+// uninstallConfirmedMsg starts an uninstall the user has confirmed
+type uninstallConfirmedMsg struct {
+	tool AITool
+}
+
+type mcpRestoreMsg struct {
+	path string
+	err  error
+}
+
+type mcpRemoveMsg struct {
+	tool  string
+	count int
+	err   error
 }
 
 type checkCompleteMsg struct{}
 
+type latestVersionsMsg struct {
+	versions map[string]string
+}
+
 type githubSyncMsg struct {
 	success bool
 	err     error
@@ -94,22 +155,16 @@ var (
 )
 
 func NewModel() Model {
-	tools := loadAITools()
-
-	// Handle case where no tools are loaded
-	if len(tools) == 0 {
-		fmt.Println("Error: No tools found in ai_tools.json")
-		fmt.Println("Please ensure ai_tools.json exists and contains valid tool definitions.")
-		os.Exit(1)
-	}
+	tools, issues := loadCatalog()
 
 	columns := []table.Column{
 		{Title: "#", Width: 4},
 		{Title: "Name", Width: 20},
 		{Title: "CLI Command", Width: 15},
-		{Title: "Status", Width: 12},
+		{Title: "Status", Width: 22},
+		{Title: "Version", Width: 12},
 		{Title: "MCP", Width: 8},
-		{Title: "Description", Width: 35},
+		{Title: "Description", Width: 30},
 	}
 
 	t := table.New(
@@ -131,16 +186,31 @@ func NewModel() Model {
 	t.SetStyles(s)
 
 	// Detect MCP config path
-	homeDir, _ := os.UserHomeDir()
-	mcpPath := filepath.Join(homeDir, "Library", "Application Support", "Claude", "claude_desktop_config.json")
+	mcpConfig := resolveMCPConfig("")
 
 	m := Model{
 		tools:         tools,
 		table:         t,
 		selected:      0,
 		mode:          "table",
+		poolAction:    "install",
 		message:       "Welcome to AI CLI Manager! Press Esc for menu.",
-		mcpConfigPath: mcpPath,
+		mcpConfigPath: mcpConfig.path,
+		mcpConfigFrom: mcpConfig.source,
+		issues:        issues,
+		logView:       viewport.New(100, 15),
+	}
+
+	// Load the lockfile so that drift can be flagged in the table
+	if lock, err := loadLock(); err == nil {
+		m.lock = lock
+	} else {
+		m.message = errorStyle.Render(fmt.Sprintf("✗ %v", err))
+	}
+
+	// Show catalog problems before anything else
+	if len(issues) > 0 || len(tools) == 0 {
+		m.mode = "errors"
 	}
 
 	// Load GitHub config
@@ -171,34 +241,153 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleConfigInput(msg)
 		case "mcp":
 			return m.handleMCPInput(msg)
+		case "backups":
+			return m.handleBackupsInput(msg)
+		case "errors":
+			return m.handleErrorsInput(msg)
+		case "confirm":
+			return m.handleConfirmInput(msg)
 		case "installing":
-			if msg.String() == "q" {
-				return m, tea.Quit
-			}
+			return m.handleInstallingInput(msg)
+		case "log":
+			return m.handleLogInput(msg)
+		case "review":
+			return m.handleReviewInput(msg)
 		}
 
+	case tea.WindowSizeMsg:
+		m.logView.Width = msg.Width - 4
+		m.logView.Height = msg.Height - 12
+		if m.logView.Height < 5 {
+			m.logView.Height = 5
+		}
+		return m, nil
+
 	case checkCompleteMsg:
+		m.updateTable()
+		return m, checkLatest(m.tools)
+
+	case latestVersionsMsg:
+		for i := range m.tools {
+			m.tools[i].Latest = msg.versions[m.tools[i].Name]
+		}
 		m.updateTable()
 		return m, nil
 
-	case installMsg:
-		m.installing = false
-		if msg.success {
-			m.message = successStyle.Render(fmt.Sprintf("✓ %s installed successfully!", msg.tool.Name))
+	case installProgressMsg:
+		if msg.event.state == "output" {
+			line := msg.event.line
+			if len(m.progress) > 1 {
+				line = fmt.Sprintf("[%s] %s", msg.event.tool.Name, line)
+			}
+			m.appendLog(line)
+			return m, waitForInstallEvent(msg.events)
+		}
+		if msg.event.state == "review" {
+			m.reviews = append(m.reviews, msg.event)
+			if m.mode != "review" {
+				m.showReview()
+			}
+			return m, waitForInstallEvent(msg.events)
+		}
+
+		m.progress[msg.event.tool.Name] = msg.event
+		switch msg.event.state {
+		case "failed":
+			m.appendLog(errorStyle.Render(fmt.Sprintf("✗ %s failed: %v (log: %s)", msg.event.tool.Name, msg.event.err, msg.event.log)))
+		case "blocked":
+			m.appendLog(errorStyle.Render(fmt.Sprintf("✗ Cannot install %s: %v", msg.event.tool.Name, msg.event.err)))
+		case "installed":
+			m.appendLog(successStyle.Render(fmt.Sprintf("✓ %s %s", msg.event.tool.Name, poolActions[m.poolAction].done)))
+		case "cancelled":
+			m.appendLog(errorStyle.Render(fmt.Sprintf("⊘ %s cancelled", msg.event.tool.Name)))
+		}
+		if msg.event.state == "installed" && m.poolAction != "upgrade" {
 			for i := range m.tools {
-				if m.tools[i].Name == msg.tool.Name {
-					m.tools[i].Installed = true
+				if m.tools[i].Name == msg.event.tool.Name {
+					m.tools[i].Installed = m.poolAction == "install"
+					if !m.tools[i].Installed {
+						m.tools[i].Version = ""
+					}
 					break
 				}
 			}
-			// After installing, configure MCP if available
-			if len(msg.tool.MCPServers) > 0 {
-				return m, m.configureMCPServers(msg.tool)
+		}
+		done := 0
+		for _, event := range m.progress {
+			if event.state == "installed" || event.state == "failed" || event.state == "blocked" || event.state == "cancelled" {
+				done++
 			}
-		} else {
-			m.message = errorStyle.Render(fmt.Sprintf("✗ Failed to install %s: %v", msg.tool.Name, msg.err))
 		}
+		m.message = fmt.Sprintf("%s... %d/%d done", poolActions[m.poolAction].running, done, len(m.progress))
 		m.updateTable()
+		return m, waitForInstallEvent(msg.events)
+
+	case installPoolDoneMsg:
+		m.installing = false
+		m.cancelInstall()
+		// Reviews left over from cancelled installs have nobody waiting
+		m.reviews = nil
+		if m.mode == "review" {
+			m.mode = "installing"
+			m.logView.SetContent(strings.Join(m.logLines, "\n"))
+		}
+		if m.quitting {
+			return m, tea.Quit
+		}
+		m.message = m.installPoolSummary()
+		m.updateTable()
+
+		var done []AITool
+		for _, event := range m.progress {
+			if event.state == "installed" {
+				done = append(done, event.tool)
+			}
+		}
+		switch m.poolAction {
+		case "install":
+			// A single install offers to configure the tool's MCP servers
+			if !m.installAllMode {
+				var tools []AITool
+				for _, tool := range done {
+					if len(tool.MCPServers) > 0 {
+						tools = append(tools, tool)
+					}
+				}
+				m.offerInstalledMCP(tools)
+			}
+		case "uninstall":
+			// Offer to clean up the MCP servers configured for the tool
+			for _, tool := range done {
+				if entries := configuredMCPServers(m.mcpTargets(), tool); len(entries) > 0 {
+					m.askConfirm(fmt.Sprintf("Also remove %d MCP server entries of %s?\n\n  %s",
+						len(entries), tool.Name, strings.Join(entries, "\n  ")), m.removeMCPServers(tool))
+				}
+			}
+		}
+		m.installAllMode = false
+		return m, checkInstallations(m.tools)
+
+	case uninstallConfirmedMsg:
+		return m.startUninstall(msg.tool)
+
+	case mcpRestoreMsg:
+		if msg.err != nil {
+			m.message = errorStyle.Render(fmt.Sprintf("✗ Failed to restore %s: %v", msg.path, msg.err))
+		} else {
+			m.message = successStyle.Render(fmt.Sprintf("✓ Restored %s", msg.path))
+		}
+		m.mcpStates = readMCPTargets(m.mcpTargets())
+		m = m.loadMCPBackups()
+		return m, nil
+
+	case mcpRemoveMsg:
+		m.mcpStates = readMCPTargets(m.mcpTargets())
+		if msg.err != nil {
+			m.message = errorStyle.Render(fmt.Sprintf("✗ Failed to remove MCP servers: %v", msg.err))
+		} else {
+			m.message = successStyle.Render(fmt.Sprintf("✓ Removed %d MCP server(s) of %s", msg.count, msg.tool))
+		}
 		return m, nil
 
 	case githubSyncMsg:
@@ -211,6 +400,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case mcpInstallMsg:
+		m.mcpStates = readMCPTargets(m.mcpTargets())
 		if msg.success {
 			m.message = successStyle.Render(fmt.Sprintf("✓ MCP servers configured for %s!", msg.tool))
 		} else {
@@ -224,11 +414,49 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m Model) View() string {
 	if m.mode == "installing" {
+		help := "↑/↓: Scroll • C: Cancel • Esc: Back to table (keeps running) • Q: Cancel and quit"
+		if !m.installing {
+			help = "↑/↓: Scroll • Esc: Back to table • Q: Quit"
+		}
 		return fmt.Sprintf(
-			"\n%s\n\n%s\n\n%s\n",
-			titleStyle.Render("AI CLI Manager - Installing"),
+			"\n%s\n\n%s\n\n%s\n\n%s\n",
+			titleStyle.Render("AI CLI Manager - "+poolActions[m.poolAction].running),
 			m.message,
-			"Press 'q' to quit",
+			menuStyle.Copy().Padding(0, 1).Render(m.logView.View()),
+			help,
+		)
+	}
+
+	if m.mode == "log" {
+		return fmt.Sprintf(
+			"\n%s\n\n%s\n\n%s\n\n%s\n",
+			titleStyle.Render("AI CLI Manager - "+m.logTitle),
+			m.logHeader,
+			menuStyle.Copy().Padding(0, 1).Render(m.logView.View()),
+			"↑/↓: Scroll • Esc: Back",
+		)
+	}
+
+	if m.mode == "review" && len(m.reviews) > 0 {
+		return fmt.Sprintf(
+			"\n%s\n\n%s\n\n%s\n\n%s\n",
+			titleStyle.Render("AI CLI Manager - Review Installer"),
+			m.logHeader,
+			menuStyle.Copy().Padding(0, 1).Render(m.logView.View()),
+			"↑/↓: Scroll • Y: Run it • N/Esc: Refuse (the install fails)",
+		)
+	}
+
+	if m.mode == "errors" {
+		return m.viewErrors()
+	}
+
+	if m.mode == "confirm" && m.confirm != nil {
+		return fmt.Sprintf(
+			"\n%s\n\n%s\n\n%s\n",
+			titleStyle.Render("AI CLI Manager - Confirm"),
+			m.confirm.prompt,
+			"Y: Yes • N/Esc: No",
 		)
 	}
 
@@ -240,10 +468,14 @@ func (m Model) View() string {
 		return m.viewMCP()
 	}
 
+	if m.mode == "backups" {
+		return m.viewMCPBackups()
+	}
+
 	if m.mode == "table" {
 		help := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("↑/↓: Navigate • Enter: Install selected • M: Configure MCP • R: Refresh status • Esc: Main menu • Q: Quit")
+			Render("↑/↓: Navigate • Enter: Install selected • G: Upgrade • U: Uninstall • L: Last log • M: Configure MCP • P: Plan mode • R: Refresh status • Esc: Main menu • Q: Quit")
 
 		statusInfo := ""
 		installedCount := 0
@@ -253,6 +485,9 @@ func (m Model) View() string {
 			}
 		}
 		statusInfo = fmt.Sprintf("Status: %d/%d tools installed", installedCount, len(m.tools))
+		if m.dryRun {
+			statusInfo += " • " + selectedStyle.Render("Plan mode: nothing is executed")
+		}
 
 		return fmt.Sprintf(
 			"\n%s\n\n%s\n\n%s\n%s\n\n%s\n",
@@ -312,4 +547,4 @@ Press 1 or Esc to return to the tools table.
 	)
 
 	return menuStyle.Render(menu)
-}
\ No newline at end of file
+}
@@ -0,0 +1,434 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// installPlan describes what installing a tool would do, without doing it
+type installPlan struct {
+	Tool   string      `json:"tool"`
+	Method string      `json:"method,omitempty"` // install method type, "github" or "command"
+	Steps  []string    `json:"steps,omitempty"`
+	Notes  []string    `json:"notes,omitempty"`
+	MCP    []mcpChange `json:"mcp_changes,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// mcpChange is what configuring a server does to one client's config
+type mcpChange struct {
+	Client string          `json:"client"` // mcpTarget ID
+	App    string          `json:"app"`
+	Path   string          `json:"path"`
+	Key    string          `json:"key"`
+	Action string          `json:"action"` // "add", "change", "unchanged" or "remove"
+	Before *MCPServerEntry `json:"before,omitempty"`
+	After  *MCPServerEntry `json:"after,omitempty"`
+	Fields []mcpFieldDiff  `json:"fields,omitempty"` // what a "change" changes
+	target mcpTarget
+}
+
+// MarshalJSON leaves out the environment values of the entries, which may
+// be secrets, as mcpFieldDiff does
+func (c mcpChange) MarshalJSON() ([]byte, error) {
+	type plain mcpChange
+	out := plain(c)
+	out.Before, out.After = redactEnv(c.Before), redactEnv(c.After)
+	return json.Marshal(out)
+}
+
+func redactEnv(entry *MCPServerEntry) *MCPServerEntry {
+	if entry == nil || len(entry.Env) == 0 {
+		return entry
+	}
+	redacted := *entry
+	redacted.Env = make(map[string]string, len(entry.Env))
+	for name := range entry.Env {
+		redacted.Env[name] = "(redacted)"
+	}
+	return &redacted
+}
+
+// mcpFieldDiff is one field of a server entry that a change modifies.
+// Environment values may be secrets, so only their names are shown.
+type mcpFieldDiff struct {
+	Field  string      `json:"field"`  // "command", "args" or "env.<NAME>"
+	Action string      `json:"action"` // "add", "change" or "remove"
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// diffMCPEntry lists the fields that differ between two server entries
+func diffMCPEntry(before, after MCPServerEntry) []mcpFieldDiff {
+	var diffs []mcpFieldDiff
+	if before.Command != after.Command {
+		diffs = append(diffs, mcpFieldDiff{Field: "command", Action: "change", Before: before.Command, After: after.Command})
+	}
+	if !sameStrings(before.Args, after.Args) {
+		diff := mcpFieldDiff{Field: "args", Action: "change", Before: before.Args, After: after.Args}
+		if len(before.Args) == 0 {
+			diff.Action, diff.Before = "add", nil
+		} else if len(after.Args) == 0 {
+			diff.Action, diff.After = "remove", nil
+		}
+		diffs = append(diffs, diff)
+	}
+
+	names := make(map[string]bool)
+	for name := range before.Env {
+		names[name] = true
+	}
+	for name := range after.Env {
+		names[name] = true
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		old, hadOld := before.Env[name]
+		value, hasNew := after.Env[name]
+		switch {
+		case !hadOld:
+			diffs = append(diffs, mcpFieldDiff{Field: "env." + name, Action: "add"})
+		case !hasNew:
+			diffs = append(diffs, mcpFieldDiff{Field: "env." + name, Action: "remove"})
+		case old != value:
+			diffs = append(diffs, mcpFieldDiff{Field: "env." + name, Action: "change"})
+		}
+	}
+	return diffs
+}
+
+// pendingMCPChanges drops the entries that are already as planned
+func pendingMCPChanges(changes []mcpChange) []mcpChange {
+	var pending []mcpChange
+	for _, change := range changes {
+		if change.Action != "unchanged" {
+			pending = append(pending, change)
+		}
+	}
+	return pending
+}
+
+// planInstall resolves the install path runInstall would take for tool and
+// the commands it would run.
+func planInstall(tool AITool) installPlan {
+	plan := installPlan{Tool: tool.Name}
+
+	if len(tool.InstallMethods) > 0 {
+		method, err := selectInstallMethod(tool)
+		if err == nil {
+			if isolated := isolatePip(method); isolated.Type != method.Type {
+				plan.Notes = append(plan.Notes, fmt.Sprintf("cannot create a virtual environment, using %s", isolated.Type))
+				method = isolated
+			}
+			pinned, err := method.pinned(tool.Constraint)
+			if err != nil {
+				plan.Error = err.Error()
+				return plan
+			}
+			plan.Method = method.Type
+			plan.Steps, err = planMethod(tool, pinned, managedPrefix(tool, method))
+			if err != nil {
+				plan.Error = err.Error()
+			}
+			return plan
+		}
+		if tool.GitHubRepo == "" && tool.InstallCmd == "" {
+			plan.Error = err.Error()
+			return plan
+		}
+		plan.Notes = append(plan.Notes, fmt.Sprintf("%v, falling back", err))
+	}
+
+	if tool.Constraint != "" {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("version %s cannot be pinned without an install method", tool.Constraint))
+	}
+
+	command := strings.Join(strings.Fields(tool.InstallCmd), " ")
+	if tool.GitHubRepo != "" {
+		dir := githubCloneDir(tool)
+		plan.Method = "github"
+		plan.Steps = []string{fmt.Sprintf("git clone %s %s", tool.GitHubRepo, dir)}
+		if tool.GitHubRef != "" {
+			plan.Steps = append(plan.Steps, "git checkout --detach "+tool.GitHubRef)
+		} else if tool.GitHubCommit != "" {
+			plan.Steps = append(plan.Steps, "git checkout --detach "+tool.GitHubCommit+" (fetching it if needed)")
+		}
+		if pinned := tool.GitHubCommit; pinned != "" || commitPattern.MatchString(tool.GitHubRef) {
+			if pinned == "" {
+				pinned = tool.GitHubRef
+			}
+			plan.Steps = append(plan.Steps, "verify the checked out commit is "+pinned)
+		} else {
+			plan.Notes = append(plan.Notes, "the repository is not pinned to a commit")
+		}
+
+		var candidates []string
+		for _, installer := range githubInstallers {
+			candidates = append(candidates, fmt.Sprintf("%s (if %s exists)", strings.Join(installer.argv, " "), installer.file))
+		}
+		if tool.ScriptSHA256 != "" {
+			plan.Steps = append(plan.Steps, "verify the installer's sha256 is "+tool.ScriptSHA256)
+		} else {
+			plan.Steps = append(plan.Steps, "show the installer for review and wait for approval")
+		}
+		plan.Steps = append(plan.Steps, fmt.Sprintf("in %s, the first of: %s", dir, strings.Join(candidates, "; ")))
+		if command != "" {
+			plan.Steps = append(plan.Steps, "if that fails: "+command)
+		}
+		return plan
+	}
+
+	if command == "" {
+		plan.Error = "no install command specified"
+		return plan
+	}
+	plan.Method = "command"
+	plan.Steps = []string{command}
+	return plan
+}
+
+// planMethod lists the steps runInstallMethod takes for method
+func planMethod(tool AITool, method InstallMethod, prefix string) ([]string, error) {
+	if prefix == "" {
+		return planUnmanaged(tool, method, managedBinDir())
+	}
+
+	var steps []string
+	if method.Type == "pip" {
+		if venv := prefixVenv(prefix); !pathExists(venv) {
+			steps = append(steps, "python3 -m venv "+venv)
+		}
+	}
+	execDir := managedExecDir(method, prefix)
+	if method.Type == "binary" {
+		binarySteps, err := planUnmanaged(tool, method, execDir)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, binarySteps...)
+	} else {
+		argv, err := method.command()
+		if err != nil {
+			return nil, err
+		}
+		argv, env := managedCommand(method, argv, prefix)
+		steps = append(steps, strings.Join(append(env, argv...), " "))
+	}
+	return append(steps, fmt.Sprintf("link %s to %s", shimPath(tool.CLICommand), filepath.Join(execDir, tool.CLICommand))), nil
+}
+
+// planUnmanaged lists the steps of a method that installs globally, with
+// binary downloads written to binDir
+func planUnmanaged(tool AITool, method InstallMethod, binDir string) ([]string, error) {
+	switch method.Type {
+	case "binary":
+		if method.SHA256 == "" {
+			return nil, fmt.Errorf("no sha256 in the catalog for %s", method.URL)
+		}
+		steps := []string{"download " + method.URL, "verify its sha256 is " + method.SHA256}
+		if archive := method.archiveType(); archive != "" {
+			name := method.Binary
+			if name == "" {
+				name = tool.CLICommand
+			}
+			steps = append(steps, fmt.Sprintf("extract %s from the %s archive", name, archive))
+		}
+		return append(steps, fmt.Sprintf("write %s (mode 0755)", filepath.Join(binDir, tool.CLICommand))), nil
+	case "script":
+		steps := []string{"download " + method.URL + " to a temporary file"}
+		if method.SHA256 != "" {
+			steps = append(steps, "verify its sha256 is "+method.SHA256)
+		} else {
+			steps = append(steps, "show the script for review and wait for approval")
+		}
+		return append(steps, strings.Join(append([]string{method.shell(), "<script>"}, method.Args...), " ")), nil
+	}
+
+	argv, err := method.command()
+	if err != nil {
+		return nil, err
+	}
+	return []string{strings.Join(argv, " ")}, nil
+}
+
+// planMCP compares the tools' MCP servers with the config of every client
+// and returns the changes that deploy each server to the clients chosen for
+// it in config, and remove it from the others.
+func planMCP(targets []mcpTarget, config appConfig, tools []AITool) ([]mcpChange, error) {
+	rows := mcpServerRows(tools)
+
+	var changes []mcpChange
+	for _, target := range targets {
+		chosen := make(map[string]bool)
+		deploying := false
+		for _, row := range rows {
+			chosen[row.key] = containsString(config.mcpServerClients(row.key), target.ID())
+			deploying = deploying || chosen[row.key]
+		}
+
+		servers, err := target.Read()
+		if err != nil && !os.IsNotExist(err) {
+			// A file nothing is deployed to only matters for removals
+			if !deploying {
+				continue
+			}
+			return nil, fmt.Errorf("cannot read %s: %w", target.Path(), err)
+		}
+
+		for _, row := range rows {
+			change := mcpChange{Client: target.ID(), App: target.App(), Path: target.Path(), Key: row.key, target: target}
+			before, present := servers[row.key]
+			if present {
+				change.Before = &before
+			}
+			switch {
+			case chosen[row.key]:
+				after := row.entry
+				change.After = &after
+				change.Action = "add"
+				if present {
+					change.Action = "change"
+					change.Fields = diffMCPEntry(before, after)
+					if len(change.Fields) == 0 {
+						change.Action = "unchanged"
+					}
+				}
+			case present:
+				change.Action = "remove"
+			default:
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// planWithMCP adds the MCP changes for the plan's tool to plan
+func planWithMCP(plan installPlan, tool AITool, targets []mcpTarget) installPlan {
+	if len(tool.MCPServers) == 0 {
+		return plan
+	}
+	changes, err := planMCP(targets, loadAppConfig(), []AITool{tool})
+	if err != nil {
+		plan.Notes = append(plan.Notes, err.Error())
+		return plan
+	}
+	plan.MCP = changes
+	return plan
+}
+
+func (e MCPServerEntry) String() string {
+	s := strings.Join(append([]string{e.Command}, e.Args...), " ")
+	if len(e.Env) > 0 {
+		var keys []string
+		for key := range e.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		s += fmt.Sprintf(" (env: %s)", strings.Join(keys, ", "))
+	}
+	return s
+}
+
+var mcpChangeMarks = map[string]string{"add": "+", "change": "~", "unchanged": "=", "remove": "-"}
+
+// writeMCPChanges prints the changes grouped by client, one line per server
+// entry followed by the fields a change modifies
+func writeMCPChanges(w io.Writer, indent string, changes []mcpChange) {
+	client := ""
+	for _, change := range changes {
+		if change.Client != client {
+			client = change.Client
+			fmt.Fprintf(w, "%s%s (%s):\n", indent, change.App, change.Path)
+		}
+		switch change.Action {
+		case "remove":
+			fmt.Fprintf(w, "%s  - %s: %s\n", indent, change.Key, change.Before)
+		case "change":
+			fmt.Fprintf(w, "%s  ~ %s\n", indent, change.Key)
+			for _, field := range change.Fields {
+				fmt.Fprintf(w, "%s      %s %s%s\n", indent, mcpChangeMarks[field.Action], field.Field, fieldValues(field))
+			}
+		default:
+			fmt.Fprintf(w, "%s  %s %s: %s\n", indent, mcpChangeMarks[change.Action], change.Key, change.After)
+		}
+	}
+}
+
+// fieldValues shows the old and new value of a field diff
+func fieldValues(field mcpFieldDiff) string {
+	show := func(v interface{}) string {
+		if args, ok := v.([]string); ok {
+			return strings.Join(args, " ")
+		}
+		return fmt.Sprint(v)
+	}
+	switch {
+	case field.Before != nil && field.After != nil:
+		return fmt.Sprintf(": %s → %s", show(field.Before), show(field.After))
+	case field.After != nil:
+		return ": " + show(field.After)
+	case field.Before != nil:
+		return ": " + show(field.Before)
+	}
+	return ""
+}
+
+// planBatch plans a batch of installs in the order they would run. Tools
+// that cannot be installed come last, with the reason as their error.
+func planBatch(batch, catalog []AITool, targets []mcpTarget) []installPlan {
+	ordered, blocked := prepareInstall(batch, catalog)
+	plans := make([]installPlan, 0, len(batch))
+	for _, tool := range ordered {
+		plans = append(plans, planWithMCP(planInstall(tool), tool, targets))
+	}
+	for _, tool := range batch {
+		if reason, ok := blocked[tool.Name]; ok {
+			plans = append(plans, installPlan{Tool: tool.Name, Error: reason})
+		}
+	}
+	return plans
+}
+
+func writePlans(w io.Writer, format string, plans []installPlan) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plans)
+	}
+
+	for i, plan := range plans {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if plan.Method != "" {
+			fmt.Fprintf(w, "%s (%s)\n", plan.Tool, plan.Method)
+		} else {
+			fmt.Fprintln(w, plan.Tool)
+		}
+		for _, step := range plan.Steps {
+			fmt.Fprintf(w, "  - %s\n", step)
+		}
+		for _, note := range plan.Notes {
+			fmt.Fprintf(w, "  note: %s\n", note)
+		}
+		if plan.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", plan.Error)
+		}
+		if len(plan.MCP) > 0 {
+			fmt.Fprintln(w, "  MCP servers:")
+			writeMCPChanges(w, "    ", plan.MCP)
+		}
+	}
+	return nil
+}
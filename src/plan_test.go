@@ -0,0 +1,33 @@
+package src
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMCPChangeJSONRedactsEnv(t *testing.T) {
+	change := mcpChange{
+		Client: "cursor",
+		Key:    "tool-server",
+		Action: "change",
+		Before: &MCPServerEntry{Command: "srv", Env: map[string]string{"API_KEY": "old-secret"}},
+		After:  &MCPServerEntry{Command: "srv", Env: map[string]string{"API_KEY": "new-secret"}},
+	}
+	change.Fields = diffMCPEntry(*change.Before, *change.After)
+
+	data, err := json.Marshal([]mcpChange{change})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("environment values leaked: %s", data)
+	}
+	if !strings.Contains(string(data), `"API_KEY":"(redacted)"`) || !strings.Contains(string(data), `"field":"env.API_KEY"`) {
+		t.Errorf("environment names missing: %s", data)
+	}
+	// Applying still uses the real values
+	if change.After.Env["API_KEY"] != "new-secret" {
+		t.Error("marshalling changed the entry")
+	}
+}
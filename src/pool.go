@@ -0,0 +1,169 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// installEvent reports the progress of one tool in the install pool
+type installEvent struct {
+	tool   AITool
+	state  string // "queued", "installing", "output", "review", "installed", "failed", "blocked" or "cancelled"
+	line   string // a line of install output for "output" events
+	log    string // the run's log file, set on "installed" and "failed"
+	err    error
+	review *scriptReview // installer awaiting approval for "review" events
+	reply  chan<- bool   // receives the decision on a "review" event
+}
+
+// installLane returns the package manager a tool will be installed with.
+// Tools in the same lane are installed one at a time, so that concurrent
+// npm, pip or brew runs do not fight over the same lock files.
+func installLane(tool AITool) string {
+	if len(tool.InstallMethods) > 0 {
+		if method, err := selectInstallMethod(tool); err == nil {
+			return method.Type
+		}
+	}
+	if tool.GitHubRepo != "" {
+		return "github"
+	}
+	return "command"
+}
+
+// runInstallPool installs tools with at most parallel installs running at
+// once, sending an event when each starts, for every line of output, and
+// when it finishes. A tool whose prerequisites are among tools waits for
+// them, and is blocked if one of them is not installed. Other failures do
+// not stop the remaining installs, but cancelling ctx stops the running
+// ones and skips the rest. Each install is bounded by timeout(tool).
+// events is closed when every tool is done.
+func runInstallPool(ctx context.Context, tools []AITool, parallel int, timeout func(AITool) time.Duration, install func(context.Context, AITool, io.Writer) error, events chan<- installEvent) {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// Slots and lanes are channels so that waiting for them can be cancelled
+	slots := make(chan struct{}, parallel)
+	lanes := make(map[string]chan struct{})
+	for _, tool := range tools {
+		lane := installLane(tool)
+		if lanes[lane] == nil {
+			lanes[lane] = make(chan struct{}, 1)
+		}
+	}
+
+	// done is closed when a tool finishes, installed records whether it succeeded
+	done := make(map[string]chan struct{})
+	for _, tool := range tools {
+		done[tool.Name] = make(chan struct{})
+	}
+	var installedMu sync.Mutex
+	installed := make(map[string]bool)
+
+	var wg sync.WaitGroup
+	for _, tool := range tools {
+		wg.Add(1)
+		go func(tool AITool, lane chan struct{}) {
+			defer wg.Done()
+			defer close(done[tool.Name])
+
+			// Wait for prerequisites before taking a lane they may need
+			for _, name := range batchPrerequisites(tool, tools) {
+				select {
+				case <-done[name]:
+				case <-ctx.Done():
+					events <- installEvent{tool: tool, state: "cancelled", err: ctx.Err()}
+					return
+				}
+				installedMu.Lock()
+				ok := installed[name]
+				installedMu.Unlock()
+				if !ok {
+					events <- installEvent{tool: tool, state: "blocked", err: fmt.Errorf("missing prerequisite: %s was not installed", name)}
+					return
+				}
+			}
+
+			// Take the lane first so that waiting tools do not hold a slot
+			if !acquire(ctx, lane) {
+				events <- installEvent{tool: tool, state: "cancelled", err: ctx.Err()}
+				return
+			}
+			defer func() { <-lane }()
+			if !acquire(ctx, slots) {
+				events <- installEvent{tool: tool, state: "cancelled", err: ctx.Err()}
+				return
+			}
+			defer func() { <-slots }()
+
+			events <- installEvent{tool: tool, state: "installing"}
+			limit := timeout(tool)
+			toolCtx, cancel := withTimeout(ctx, limit)
+			logPath, err := runLogged(toolCtx, tool, install, events)
+			cancel()
+
+			switch {
+			case err == nil:
+				installedMu.Lock()
+				installed[tool.Name] = true
+				installedMu.Unlock()
+				events <- installEvent{tool: tool, state: "installed", log: logPath}
+			case ctx.Err() != nil:
+				events <- installEvent{tool: tool, state: "cancelled", log: logPath, err: ctx.Err()}
+			case errors.Is(err, context.DeadlineExceeded):
+				events <- installEvent{tool: tool, state: "failed", log: logPath, err: fmt.Errorf("timed out after %s", limit)}
+			default:
+				events <- installEvent{tool: tool, state: "failed", log: logPath, err: err}
+			}
+		}(tool, lanes[installLane(tool)])
+	}
+
+	wg.Wait()
+	close(events)
+}
+
+// acquire takes a token from sem, giving up when ctx is done
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+		// Both may be ready; do not start anything once cancelled
+		if ctx.Err() != nil {
+			<-sem
+			return false
+		}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// runLogged runs install, streaming its output as events and into a new
+// log file for the tool, and returns the log file's path.
+func runLogged(ctx context.Context, tool AITool, install func(context.Context, AITool, io.Writer) error, events chan<- installEvent) (string, error) {
+	lines := &lineWriter{emit: func(line string) {
+		events <- installEvent{tool: tool, state: "output", line: line}
+	}}
+
+	var out io.Writer = lines
+	logPath := ""
+	logFile, err := createInstallLog(tool)
+	if err != nil {
+		lines.emit(fmt.Sprintf("Warning: cannot write install log: %v", err))
+	} else {
+		defer logFile.Close()
+		logPath = logFile.Name()
+		out = io.MultiWriter(logFile, lines)
+	}
+
+	err = install(ctx, tool, out)
+	lines.Flush()
+	if err != nil && logFile != nil {
+		fmt.Fprintf(logFile, "Error: %v\n", err)
+	}
+	return logPath, err
+}
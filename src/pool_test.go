@@ -0,0 +1,177 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolTool is a tool in the "command" lane, or the "github" lane when
+// github is set
+func poolTool(name string, github bool) AITool {
+	tool := AITool{Name: name, CLICommand: "ai-cli-manager-test-" + name}
+	if github {
+		tool.GitHubRepo = "https://github.com/example/" + name
+	}
+	return tool
+}
+
+func noTimeout(AITool) time.Duration { return 0 }
+
+// runPool runs the pool to completion and returns each tool's final state
+// and the output lines it sent
+func runPool(t *testing.T, ctx context.Context, tools []AITool, parallel int, install func(context.Context, AITool, io.Writer) error, onEvent func(installEvent)) (map[string]string, map[string][]string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	events := make(chan installEvent)
+	go runInstallPool(ctx, tools, parallel, noTimeout, install, events)
+
+	states := make(map[string]string)
+	output := make(map[string][]string)
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return states, output
+			}
+			if onEvent != nil {
+				onEvent(event)
+			}
+			if event.state == "output" {
+				output[event.tool.Name] = append(output[event.tool.Name], event.line)
+			} else {
+				states[event.tool.Name] = event.state
+			}
+		case <-timeout:
+			t.Fatal("the pool did not finish")
+		}
+	}
+}
+
+func TestInstallPoolLanes(t *testing.T) {
+	tools := []AITool{poolTool("a", false), poolTool("b", false), poolTool("c", false), poolTool("gh", true)}
+
+	var mu sync.Mutex
+	running := make(map[string]int)
+	most := make(map[string]int)
+	ghStarted := make(chan struct{})
+	install := func(ctx context.Context, tool AITool, out io.Writer) error {
+		lane := installLane(tool)
+		mu.Lock()
+		running[lane]++
+		if running[lane] > most[lane] {
+			most[lane] = running[lane]
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running[lane]--
+			mu.Unlock()
+		}()
+
+		if tool.GitHubRepo != "" {
+			close(ghStarted)
+		} else {
+			// The other lane runs alongside this one
+			select {
+			case <-ghStarted:
+			case <-time.After(5 * time.Second):
+				return errors.New("the github lane did not run in parallel")
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(out, "installed %s\n", tool.Name)
+		return nil
+	}
+
+	states, output := runPool(t, context.Background(), tools, 4, install, nil)
+	for _, tool := range tools {
+		if states[tool.Name] != "installed" {
+			t.Errorf("%s ended %q, want installed", tool.Name, states[tool.Name])
+		}
+		if len(output[tool.Name]) != 1 || output[tool.Name][0] != "installed "+tool.Name {
+			t.Errorf("%s output = %q", tool.Name, output[tool.Name])
+		}
+	}
+	if most["command"] != 1 {
+		t.Errorf("%d installs ran at once in one lane, want 1", most["command"])
+	}
+}
+
+func TestInstallPoolContinuesPastFailures(t *testing.T) {
+	needsA := poolTool("needs-a", true)
+	needsA.Prerequisites = []Prerequisite{{Tool: "a"}}
+	tools := []AITool{poolTool("a", false), needsA, poolTool("b", false), poolTool("c", true)}
+
+	install := func(ctx context.Context, tool AITool, out io.Writer) error {
+		if tool.Name == "a" {
+			fmt.Fprint(out, "npm ERR! boom")
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+
+	states, output := runPool(t, context.Background(), tools, 2, install, nil)
+	want := map[string]string{"a": "failed", "needs-a": "blocked", "b": "installed", "c": "installed"}
+	for name, state := range want {
+		if states[name] != state {
+			t.Errorf("%s ended %q, want %q", name, states[name], state)
+		}
+	}
+	if len(output["a"]) != 1 || output["a"][0] != "npm ERR! boom" {
+		t.Errorf("unterminated output was not flushed: %q", output["a"])
+	}
+}
+
+func TestInstallPoolCancel(t *testing.T) {
+	tools := []AITool{poolTool("a", false), poolTool("b", false), poolTool("c", true)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan string, len(tools))
+	install := func(ctx context.Context, tool AITool, out io.Writer) error {
+		started <- tool.Name
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	onEvent := func(event installEvent) {
+		if event.state == "installing" {
+			cancel()
+		}
+	}
+
+	states, _ := runPool(t, ctx, tools, 1, install, onEvent)
+	for _, tool := range tools {
+		if states[tool.Name] != "cancelled" {
+			t.Errorf("%s ended %q, want cancelled", tool.Name, states[tool.Name])
+		}
+	}
+	close(started)
+	if n := len(started); n != 1 {
+		t.Errorf("%d installs started, want only the one running when cancelled", n)
+	}
+}
+
+func TestInstallPoolTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tools := []AITool{poolTool("slow", false)}
+	install := func(ctx context.Context, tool AITool, out io.Writer) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	events := make(chan installEvent)
+	go runInstallPool(context.Background(), tools, 1, func(AITool) time.Duration { return 10 * time.Millisecond }, install, events)
+
+	var last installEvent
+	for event := range events {
+		last = event
+	}
+	if last.state != "failed" || last.err == nil || last.err.Error() != "timed out after 10ms" {
+		t.Errorf("last event = %s %v, want failed with a timeout", last.state, last.err)
+	}
+}
@@ -0,0 +1,235 @@
+package src
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Prerequisite is something that must be present before a tool can be
+// installed: a command such as a language runtime, or another catalog tool
+type Prerequisite struct {
+	Command    string `json:"command,omitempty"`     // command that must be on PATH, e.g. "node"
+	MinVersion string `json:"min_version,omitempty"` // lowest acceptable version of the command
+	VersionCmd string `json:"version_cmd,omitempty"` // prints the command's version, defaults to "<command> --version"
+	Tool       string `json:"tool,omitempty"`        // name of a catalog tool
+}
+
+func (p Prerequisite) String() string {
+	switch {
+	case p.Tool != "":
+		return p.Tool
+	case p.MinVersion != "":
+		return fmt.Sprintf("%s >= %s", p.Command, p.MinVersion)
+	}
+	return p.Command
+}
+
+// versionArgs print the version of commands that do not accept --version
+var versionArgs = map[string][]string{"go": {"version"}}
+
+// checkCommand reports why a command prerequisite is not met, or nil
+func (p Prerequisite) checkCommand() error {
+	path, err := lookCommand(p.Command)
+	if err != nil {
+		return fmt.Errorf("%s not found", p)
+	}
+	if p.MinVersion == "" {
+		return nil
+	}
+
+	argv := strings.Fields(p.VersionCmd)
+	if len(argv) == 0 {
+		args, ok := versionArgs[p.Command]
+		if !ok {
+			args = []string{"--version"}
+		}
+		argv = append([]string{path}, args...)
+	}
+	output, err := exec.Command(argv[0], argv[1:]...).CombinedOutput()
+	version := versionPattern.FindString(string(output))
+	if err != nil || version == "" {
+		return fmt.Errorf("%s needed, cannot tell which version is installed", p)
+	}
+	if compareVersions(version, p.MinVersion) < 0 {
+		return fmt.Errorf("%s needed, found %s", p, version)
+	}
+	return nil
+}
+
+// routeMissing reports the command that the tool's install route needs
+// and that is missing: the package manager of its install methods, git for
+// GitHub installs or the program its install command runs.
+func routeMissing(tool AITool) error {
+	if len(tool.InstallMethods) > 0 {
+		if _, err := selectInstallMethod(tool); err == nil {
+			return nil
+		}
+		if tool.GitHubRepo == "" && tool.InstallCmd == "" {
+			// Methods for other platforms are not a missing prerequisite
+			var alternatives []string
+			for _, method := range tool.InstallMethods {
+				if missing := method.missingCommands(); len(missing) > 0 {
+					alternatives = append(alternatives, strings.Join(missing, " and "))
+				}
+			}
+			if len(alternatives) > 0 {
+				return fmt.Errorf("%s not found", strings.Join(alternatives, " or "))
+			}
+			return nil
+		}
+	}
+
+	if tool.GitHubRepo != "" {
+		_, err := exec.LookPath("git")
+		if err == nil {
+			return nil
+		}
+		if tool.InstallCmd == "" {
+			return fmt.Errorf("git not found")
+		}
+	}
+	if parts := strings.Fields(tool.InstallCmd); len(parts) > 0 {
+		if _, err := exec.LookPath(parts[0]); err != nil {
+			return fmt.Errorf("%s not found", parts[0])
+		}
+	}
+	return nil
+}
+
+// missingCommands lists the commands a method needs and cannot find, if it
+// is otherwise usable on this host
+func (im InstallMethod) missingCommands() []string {
+	if im.supportsHost() != nil {
+		return nil
+	}
+	if _, err := im.forHost(); err != nil {
+		return nil
+	}
+
+	var missing []string
+	if _, err := im.manager(); err != nil {
+		managers, _ := im.managers()
+		missing = append(missing, strings.Join(managers, "/"))
+	}
+	for _, name := range im.Requires {
+		if _, err := exec.LookPath(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// missingPrerequisites lists what has to be installed before tool can be.
+// Catalog tools named in pending are about to be installed and count as
+// present.
+func missingPrerequisites(tool AITool, catalog []AITool, pending map[string]bool) []string {
+	var missing []string
+	for _, p := range tool.Prerequisites {
+		if p.Tool == "" {
+			if err := p.checkCommand(); err != nil {
+				missing = append(missing, err.Error())
+			}
+			continue
+		}
+
+		i, ok := findTool(catalog, p.Tool)
+		switch {
+		case !ok:
+			missing = append(missing, fmt.Sprintf("%s needed, but it is not in the catalog", p.Tool))
+		case pending[catalog[i].Name]:
+		case !catalog[i].Installed && !isInstalled(catalog[i]):
+			missing = append(missing, fmt.Sprintf("%s is not installed", catalog[i].Name))
+		}
+	}
+	if err := routeMissing(tool); err != nil {
+		missing = append(missing, err.Error())
+	}
+	return missing
+}
+
+// checkPrerequisites sets Missing on every tool that is not installed.
+// Installed must be up to date.
+func checkPrerequisites(tools []AITool) {
+	for i := range tools {
+		tools[i].Missing = nil
+		if !tools[i].Installed {
+			tools[i].Missing = missingPrerequisites(tools[i], tools, nil)
+		}
+	}
+}
+
+// batchPrerequisites returns the names of the tools in batch that tool
+// needs installed first
+func batchPrerequisites(tool AITool, batch []AITool) []string {
+	var names []string
+	for _, p := range tool.Prerequisites {
+		if p.Tool == "" {
+			continue
+		}
+		if i, ok := findTool(batch, p.Tool); ok && batch[i].Name != tool.Name {
+			names = append(names, batch[i].Name)
+		}
+	}
+	return names
+}
+
+// prepareInstall orders a batch of tools so that each comes after the
+// tools it needs, keeping the given order where there is a choice. Tools
+// that cannot be installed are returned separately with the reason: a
+// missing prerequisite, a dependency cycle, or a prerequisite in the batch
+// that itself cannot be installed.
+func prepareInstall(batch, catalog []AITool) ([]AITool, map[string]string) {
+	pending := make(map[string]bool)
+	for _, tool := range batch {
+		pending[tool.Name] = true
+	}
+
+	blocked := make(map[string]string)
+	for _, tool := range batch {
+		if missing := missingPrerequisites(tool, catalog, pending); len(missing) > 0 {
+			blocked[tool.Name] = "missing prerequisite: " + strings.Join(missing, "; ")
+		}
+	}
+
+	var ordered []AITool
+	placed := make(map[string]bool)
+	for len(ordered)+len(blocked) < len(batch) {
+		progressed := false
+		for _, tool := range batch {
+			if placed[tool.Name] || blocked[tool.Name] != "" {
+				continue
+			}
+			ready := true
+			for _, name := range batchPrerequisites(tool, batch) {
+				if reason := blocked[name]; reason != "" {
+					blocked[tool.Name] = fmt.Sprintf("missing prerequisite: %s cannot be installed", name)
+					progressed = true
+					ready = false
+					break
+				}
+				if !placed[name] {
+					ready = false
+				}
+			}
+			if ready {
+				ordered = append(ordered, tool)
+				placed[tool.Name] = true
+				progressed = true
+			}
+		}
+		if !progressed {
+			// Whatever is left waits on itself
+			var cycle []string
+			for _, tool := range batch {
+				if !placed[tool.Name] && blocked[tool.Name] == "" {
+					cycle = append(cycle, tool.Name)
+				}
+			}
+			for _, name := range cycle {
+				blocked[name] = "prerequisite cycle among " + strings.Join(cycle, ", ")
+			}
+		}
+	}
+	return ordered, blocked
+}
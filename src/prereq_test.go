@@ -0,0 +1,144 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// batchTool is a tool that is not installed, with prerequisites on other
+// catalog tools and nothing else to check
+func batchTool(name string, needs ...string) AITool {
+	tool := AITool{Name: name, CLICommand: "ai-cli-manager-test-" + strings.ToLower(name)}
+	for _, need := range needs {
+		tool.Prerequisites = append(tool.Prerequisites, Prerequisite{Tool: need})
+	}
+	return tool
+}
+
+func toolNames(tools []AITool) string {
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestPrepareInstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	missingCommand := Prerequisite{Command: "ai-cli-manager-test-missing"}
+	broken := batchTool("Broken")
+	broken.Prerequisites = []Prerequisite{missingCommand}
+
+	tests := []struct {
+		name    string
+		batch   []AITool
+		ordered string
+		blocked map[string]string
+	}{
+		{
+			name:    "chain",
+			batch:   []AITool{batchTool("A", "B"), batchTool("B", "C"), batchTool("C")},
+			ordered: "C B A",
+		},
+		{
+			name:    "tools without prerequisites are not held back",
+			batch:   []AITool{batchTool("X"), batchTool("A", "B"), batchTool("B"), batchTool("Y")},
+			ordered: "X B Y A",
+		},
+		{
+			name:    "2-cycle",
+			batch:   []AITool{batchTool("A", "B"), batchTool("B", "A"), batchTool("C")},
+			ordered: "C",
+			blocked: map[string]string{
+				"A": "prerequisite cycle among A, B",
+				"B": "prerequisite cycle among A, B",
+			},
+		},
+		{
+			name:    "blocked prerequisite in the batch",
+			batch:   []AITool{batchTool("A", "B"), batchTool("B", "Broken"), broken, batchTool("C")},
+			ordered: "C",
+			blocked: map[string]string{
+				"Broken": "missing prerequisite: ai-cli-manager-test-missing not found",
+				"B":      "missing prerequisite: Broken cannot be installed",
+				"A":      "missing prerequisite: B cannot be installed",
+			},
+		},
+		{
+			name:    "prerequisite outside the batch and not installed",
+			batch:   []AITool{batchTool("A", "B")},
+			blocked: map[string]string{"A": "missing prerequisite: B is not installed"},
+		},
+		{
+			name:    "prerequisite not in the catalog",
+			batch:   []AITool{batchTool("A", "Nowhere")},
+			blocked: map[string]string{"A": "missing prerequisite: Nowhere needed, but it is not in the catalog"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := append([]AITool{batchTool("B")}, tt.batch...)
+			ordered, blocked := prepareInstall(tt.batch, catalog)
+			if got := toolNames(ordered); got != tt.ordered {
+				t.Errorf("ordered = %q, want %q", got, tt.ordered)
+			}
+			if len(blocked) != len(tt.blocked) {
+				t.Errorf("blocked = %v, want %v", blocked, tt.blocked)
+			}
+			for name, want := range tt.blocked {
+				if blocked[name] != want {
+					t.Errorf("blocked[%s] = %q, want %q", name, blocked[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchPrerequisites(t *testing.T) {
+	batch := []AITool{batchTool("A", "B", "C", "A"), batchTool("B"), batchTool("C")}
+	tool := batch[0]
+	tool.Prerequisites = append(tool.Prerequisites, Prerequisite{Command: "node"}, Prerequisite{Tool: "Elsewhere"})
+	if got := strings.Join(batchPrerequisites(tool, batch), " "); got != "B C" {
+		t.Errorf("batchPrerequisites = %q, want %q", got, "B C")
+	}
+}
+
+func TestCheckCommandMinVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the command")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"fake version 1.4.2 (build 7)\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "ai-cli-manager-test-fake"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		prereq Prerequisite
+		want   string
+	}{
+		{Prerequisite{Command: "ai-cli-manager-test-fake"}, ""},
+		{Prerequisite{Command: "ai-cli-manager-test-fake", MinVersion: "1.4"}, ""},
+		{Prerequisite{Command: "ai-cli-manager-test-fake", MinVersion: "1.4.2"}, ""},
+		{Prerequisite{Command: "ai-cli-manager-test-fake", MinVersion: "v1.3.9"}, ""},
+		{Prerequisite{Command: "ai-cli-manager-test-fake", MinVersion: "1.5"}, "ai-cli-manager-test-fake >= 1.5 needed, found 1.4.2"},
+		{Prerequisite{Command: "ai-cli-manager-test-fake", MinVersion: "2"}, "ai-cli-manager-test-fake >= 2 needed, found 1.4.2"},
+		{Prerequisite{Command: "ai-cli-manager-test-fake", MinVersion: "1.0", VersionCmd: "true"}, "ai-cli-manager-test-fake >= 1.0 needed, cannot tell which version is installed"},
+		{Prerequisite{Command: "ai-cli-manager-test-missing", MinVersion: "1.0"}, "ai-cli-manager-test-missing >= 1.0 not found"},
+	}
+	for _, tt := range tests {
+		err := tt.prereq.checkCommand()
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("checkCommand(%s) = %q, want %q", tt.prereq, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !windows
+
+package src
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// killGrace is how long a cancelled command gets to exit after SIGTERM
+// before its process group is killed.
+const killGrace = 5 * time.Second
+
+// killProcessGroup runs cmd in its own process group and makes cancelling
+// it terminate the whole group, so that children spawned by npm, pip or an
+// install script do not outlive it. The returned function must be called
+// once cmd has been waited for: it stops a pending SIGKILL, which could
+// otherwise hit a new process group that reused the ID.
+func killProcessGroup(cmd *exec.Cmd) func() {
+	var mu sync.Mutex
+	var kill *time.Timer
+	waited := false
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		mu.Lock()
+		if !waited {
+			kill = time.AfterFunc(killGrace, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if !waited {
+					syscall.Kill(pgid, syscall.SIGKILL)
+				}
+			})
+		}
+		mu.Unlock()
+		return syscall.Kill(pgid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = 2 * killGrace
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		waited = true
+		if kill != nil {
+			kill.Stop()
+		}
+	}
+}
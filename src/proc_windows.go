@@ -0,0 +1,20 @@
+//go:build windows
+
+package src
+
+import (
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// killProcessGroup makes cancelling cmd terminate its whole process tree,
+// so that children spawned by npm, pip or an install script do not
+// outlive it. The returned function is called once cmd has been waited for.
+func killProcessGroup(cmd *exec.Cmd) func() {
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+	cmd.WaitDelay = 10 * time.Second
+	return func() {}
+}
@@ -0,0 +1,162 @@
+package src
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+var (
+	// commitPattern matches a full or abbreviated git commit SHA
+	commitPattern     = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+	fullCommitPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	sha256Pattern     = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+)
+
+// scriptReview is an installer, from a cloned repository or a script
+// method's URL, awaiting approval
+type scriptReview struct {
+	tool     AITool
+	source   string // repository or URL the installer came from
+	file     string // installer file, relative to the clone, or the URL
+	command  string // command that would run it
+	commit   string // checked out commit, empty if the tool is not pinned
+	content  []byte
+	sha256   string
+	approved string // pre-approved sha256 from the catalog, if any
+	field    string // catalog field that pre-approves it
+}
+
+// scriptReviewer decides whether a reviewed installer may run; it returns
+// nil to approve it
+type scriptReviewer func(context.Context, scriptReview) error
+
+// errScriptNotApproved is returned when an installer needs a review that
+// nobody can give
+var errScriptNotApproved = errors.New("installer needs approval")
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// approveScript lets an installer run if its hash matches the one the
+// catalog approved, and otherwise asks review. A mismatching hash is never
+// offered for review: the script changed since it was approved.
+func approveScript(ctx context.Context, r scriptReview, review scriptReviewer, out io.Writer) error {
+	if r.approved != "" {
+		if !strings.EqualFold(r.approved, r.sha256) {
+			return fmt.Errorf("%s has sha256 %s, which does not match the approved %s", r.file, r.sha256, r.approved)
+		}
+		fmt.Fprintf(out, "%s matches the approved sha256 %s\n", r.file, r.sha256)
+		return nil
+	}
+
+	if review == nil {
+		return fmt.Errorf("%w: %s (sha256 %s); set %s in the catalog to pre-approve it", errScriptNotApproved, r.file, r.sha256, r.field)
+	}
+	if err := review(ctx, r); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s (sha256 %s) approved\n", r.file, r.sha256)
+	return nil
+}
+
+// describe is the header shown above an installer under review
+func (r scriptReview) describe() string {
+	commit := r.commit
+	if commit == "" {
+		commit = "not pinned"
+	}
+	return fmt.Sprintf("%s wants to run `%s` from %s\ncommit: %s\nsha256: %s", r.tool.Name, r.command, r.source, commit, r.sha256)
+}
+
+// terminalMu keeps concurrent reviews from interleaving their prompts
+var terminalMu sync.Mutex
+
+// terminalLine is a line read from stdin and when it was read
+type terminalLine struct {
+	text string
+	at   time.Time
+}
+
+var (
+	stdinOnce  sync.Once
+	stdinLines chan terminalLine
+)
+
+// readStdin starts the one goroutine that reads stdin, so that a review
+// cancelled while waiting for an answer leaves no reader behind to swallow
+// the next line. The channel is closed when stdin is.
+func readStdin() <-chan terminalLine {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan terminalLine)
+		go func() {
+			stdin := bufio.NewReader(os.Stdin)
+			for {
+				text, err := stdin.ReadString('\n')
+				if text != "" {
+					stdinLines <- terminalLine{text: text, at: time.Now()}
+				}
+				if err != nil {
+					close(stdinLines)
+					return
+				}
+			}
+		}()
+	})
+	return stdinLines
+}
+
+// terminalReviewer shows installers on stderr and asks for approval on
+// stdin. When stdin is not a terminal nothing can be approved.
+func terminalReviewer() scriptReviewer {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return promptReviewer(readStdin(), os.Stderr)
+}
+
+// promptReviewer shows installers on out and takes the answer from lines.
+// Only a line read after the prompt counts: one typed while no review was
+// waiting, such as after a cancelled one, is discarded.
+func promptReviewer(lines <-chan terminalLine, out io.Writer) scriptReviewer {
+	return func(ctx context.Context, r scriptReview) error {
+		terminalMu.Lock()
+		defer terminalMu.Unlock()
+
+		fmt.Fprintf(out, "\n%s\n----- %s -----\n%s\n----- end of %s -----\n", r.describe(), r.file, r.content, r.file)
+		fmt.Fprintf(out, "Run %s? [y/N] ", r.file)
+		asked := time.Now()
+
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return fmt.Errorf("%s was not approved", r.file)
+				}
+				if line.at.Before(asked) {
+					continue
+				}
+				switch strings.ToLower(strings.TrimSpace(line.text)) {
+				case "y", "yes":
+					return nil
+				}
+				return fmt.Errorf("%s was not approved", r.file)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package src
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestPromptReviewerAfterCancel(t *testing.T) {
+	lines := make(chan terminalLine)
+	review := promptReviewer(lines, io.Discard)
+	r := scriptReview{tool: AITool{Name: "Fake"}, file: "install.sh", content: []byte("echo hi\n")}
+
+	// The first review is cancelled while it waits for an answer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- review(ctx, r) }()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("cancelled review returned %v", err)
+	}
+
+	// A line typed before the next prompt does not answer it
+	stale := terminalLine{text: "y\n", at: time.Now()}
+	go func() { done <- review(context.Background(), r) }()
+	lines <- stale
+	lines <- terminalLine{text: "n\n", at: time.Now().Add(time.Hour)}
+	if err := <-done; err == nil {
+		t.Fatal("a line typed before the prompt approved the script")
+	}
+
+	go func() { done <- review(context.Background(), r) }()
+	lines <- terminalLine{text: "yes\n", at: time.Now().Add(time.Hour)}
+	if err := <-done; err != nil {
+		t.Fatalf("approved review returned %v", err)
+	}
+
+	close(lines)
+	if err := review(context.Background(), r); err == nil {
+		t.Fatal("closed stdin approved the script")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package src
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// shimPath is the managed bin directory entry for a command
+func shimPath(name string) string {
+	return filepath.Join(managedBinDir(), name)
+}
+
+// writeShim makes shim a symlink to target, replacing what was there
+func writeShim(shim, target string) error {
+	if err := os.Remove(shim); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(target, shim)
+}
+
+// shimTarget returns the executable a shim points at
+func shimTarget(shim string) (string, error) {
+	return os.Readlink(shim)
+}
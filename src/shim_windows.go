@@ -0,0 +1,37 @@
+//go:build windows
+
+package src
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shimPath is the managed bin directory entry for a command. Symlinks need
+// extra privileges on Windows, so shims are batch files.
+func shimPath(name string) string {
+	return filepath.Join(managedBinDir(), name+".cmd")
+}
+
+// writeShim makes shim a batch file that runs target, replacing what was there
+func writeShim(shim, target string) error {
+	return os.WriteFile(shim, []byte(fmt.Sprintf("@echo off\r\n\"%s\" %%*\r\n", target)), 0755)
+}
+
+// shimTarget returns the executable a shim runs
+func shimTarget(shim string) (string, error) {
+	data, err := os.ReadFile(shim)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, `"`) {
+			if end := strings.Index(line[1:], `"`); end >= 0 {
+				return line[1 : end+1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s is not a shim", shim)
+}
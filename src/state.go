@@ -0,0 +1,110 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// installRecord remembers how a tool was installed so that it can later be
+// uninstalled or upgraded the same way.
+type installRecord struct {
+	Method      string         `json:"method"` // an install method type, "github" or "command"
+	Spec        *InstallMethod `json:"spec,omitempty"`
+	Command     string         `json:"command,omitempty"`
+	Repo        string         `json:"repo,omitempty"`
+	Commit      string         `json:"commit,omitempty"` // commit a github install was built from
+	Prefix      string         `json:"prefix,omitempty"` // managed prefix the tool was installed into
+	Venv        string         `json:"venv,omitempty"`   // virtual environment of a pip install
+	InstalledAt time.Time      `json:"installed_at"`
+}
+
+type installState struct {
+	Tools map[string]installRecord `json:"tools"`
+}
+
+// stateMu serializes read-modify-write cycles of the state file
+var stateMu sync.Mutex
+
+func installStatePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".ai-cli-manager", "state.json")
+}
+
+func loadInstallState() installState {
+	state := installState{Tools: make(map[string]installRecord)}
+	data, err := os.ReadFile(installStatePath())
+	if err != nil {
+		return state
+	}
+	json.Unmarshal(data, &state)
+	if state.Tools == nil {
+		state.Tools = make(map[string]installRecord)
+	}
+	return state
+}
+
+func saveInstallState(state installState) error {
+	path := installStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func updateInstallState(update func(*installState)) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state := loadInstallState()
+	update(&state)
+	return saveInstallState(state)
+}
+
+func recordInstall(tool AITool, record installRecord) error {
+	record.InstalledAt = time.Now()
+	return updateInstallState(func(state *installState) {
+		state.Tools[tool.Name] = record
+	})
+}
+
+func forgetInstall(tool AITool) error {
+	return updateInstallState(func(state *installState) {
+		delete(state.Tools, tool.Name)
+	})
+}
+
+// installedMethod works out how a tool was installed: from the install
+// record if this manager installed it, otherwise from the catalog.
+func installedMethod(tool AITool) (InstallMethod, error) {
+	if record, ok := loadInstallState().Tools[tool.Name]; ok {
+		switch record.Method {
+		case "github":
+			return InstallMethod{}, fmt.Errorf("%s was installed from %s, not with a package manager", tool.Name, record.Repo)
+		case "command":
+			if method, ok := parseInstallCmd(record.Command); ok {
+				return method, nil
+			}
+			return InstallMethod{}, fmt.Errorf("%s was installed with %q, not with a known package manager", tool.Name, record.Command)
+		default:
+			if record.Spec != nil {
+				return *record.Spec, nil
+			}
+		}
+	}
+
+	if len(tool.InstallMethods) > 0 {
+		return selectInstallMethod(tool)
+	}
+	if method, ok := parseInstallCmd(tool.InstallCmd); ok {
+		return method, nil
+	}
+	return InstallMethod{}, fmt.Errorf("unknown how %s was installed", tool.Name)
+}
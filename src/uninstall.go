@@ -0,0 +1,102 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// uninstallCommand returns the argv that removes the package installed with
+// this method. Methods that install a single file have no command.
+func (im InstallMethod) uninstallCommand() ([]string, error) {
+	manager, err := im.manager()
+	if err != nil {
+		return nil, err
+	}
+
+	switch im.Type {
+	case "npm":
+		return []string{manager, "uninstall", "-g", im.Package}, nil
+	case "pip":
+		return []string{manager, "uninstall", "-y", im.Package}, nil
+	case "pipx", "cargo":
+		return []string{manager, "uninstall", im.Package}, nil
+	case "uv":
+		return []string{manager, "tool", "uninstall", im.Package}, nil
+	case "brew":
+		argv := []string{manager, "uninstall"}
+		if containsString(im.Args, "--cask") {
+			argv = append(argv, "--cask")
+		}
+		return append(argv, im.Package), nil
+	case "apt":
+		return withSudo([]string{manager, "remove", "-y", im.Package}), nil
+	case "script":
+		return nil, fmt.Errorf("tools installed by script cannot be uninstalled automatically")
+	}
+	return nil, fmt.Errorf("%s method has no uninstall command", im.Type)
+}
+
+// goBinDir returns the directory go install places binaries in
+func goBinDir() (string, error) {
+	output, err := exec.Command("go", "env", "GOBIN", "GOPATH").Output()
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+		return strings.TrimSpace(lines[0]), nil
+	}
+	if len(lines) > 1 {
+		gopath := filepath.SplitList(strings.TrimSpace(lines[1]))
+		if len(gopath) > 0 && gopath[0] != "" {
+			return filepath.Join(gopath[0], "bin"), nil
+		}
+	}
+	return "", fmt.Errorf("cannot determine the go binary directory")
+}
+
+func removeFile(path string, out io.Writer) error {
+	fmt.Fprintf(out, "Removing %s\n", path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// runUninstall removes a tool the same way it was installed
+func runUninstall(ctx context.Context, tool AITool, out io.Writer) error {
+	method, err := installedMethod(tool)
+	if err != nil {
+		return err
+	}
+
+	switch prefix := loadInstallState().Tools[tool.Name].Prefix; {
+	case prefix != "":
+		err = removeManaged(tool, prefix, out)
+	case method.Type == "binary":
+		err = removeFile(filepath.Join(managedBinDir(), tool.CLICommand), out)
+	case method.Type == "go":
+		var binDir string
+		if binDir, err = goBinDir(); err == nil {
+			err = removeFile(filepath.Join(binDir, tool.CLICommand), out)
+		}
+	default:
+		var argv []string
+		if argv, err = method.uninstallCommand(); err == nil {
+			err = runCommand(ctx, out, argv)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := forgetInstall(tool); err != nil {
+		fmt.Fprintf(out, "Warning: could not update install state: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// upgradeCommand returns the argv that upgrades the package installed with
+// this method. Binary and script methods are upgraded by reinstalling.
+func (im InstallMethod) upgradeCommand() ([]string, error) {
+	manager, err := im.manager()
+	if err != nil {
+		return nil, err
+	}
+
+	switch im.Type {
+	case "npm":
+		return []string{manager, "update", "-g", im.Package}, nil
+	case "pip":
+		return append(append([]string{manager, "install", "-U"}, im.Args...), im.Package), nil
+	case "pipx":
+		return []string{manager, "upgrade", im.Package}, nil
+	case "uv":
+		return []string{manager, "tool", "upgrade", im.Package}, nil
+	case "go":
+		module := strings.SplitN(im.Package, "@", 2)[0]
+		return append(append([]string{manager, "install"}, im.Args...), module+"@latest"), nil
+	case "cargo":
+		return append(append([]string{manager, "install"}, im.Args...), im.Package), nil
+	case "brew":
+		argv := []string{manager, "upgrade"}
+		if containsString(im.Args, "--cask") {
+			argv = append(argv, "--cask")
+		}
+		return append(argv, im.Package), nil
+	case "apt":
+		return withSudo([]string{manager, "install", "--only-upgrade", "-y", im.Package}), nil
+	}
+	return nil, fmt.Errorf("%s method has no upgrade command", im.Type)
+}
+
+// runUpgrade upgrades a tool through the same channel it was installed with
+// and returns the versions detected before and after.
+func runUpgrade(ctx context.Context, tool AITool, out io.Writer, review scriptReviewer) (string, string, error) {
+	_, before := detectTool(tool)
+
+	err := upgradeTool(ctx, tool, out, review)
+	if err != nil {
+		return before, before, err
+	}
+
+	_, after := detectTool(tool)
+	return before, after, nil
+}
+
+func upgradeTool(ctx context.Context, tool AITool, out io.Writer, review scriptReviewer) error {
+	// Tools built from their repository are re-cloned and rebuilt
+	record, ok := loadInstallState().Tools[tool.Name]
+	if ok && record.Method == "github" {
+		commit, err := installFromGitHub(ctx, tool, out, review)
+		if err != nil {
+			return err
+		}
+		record.Commit = commit
+		return finishInstall(tool, record, out)
+	}
+
+	method, err := installedMethod(tool)
+	if err != nil {
+		return err
+	}
+
+	// Managed installs are upgraded in the prefix they were installed into
+	prefix := record.Prefix
+	switch {
+	case tool.Constraint != "":
+		// Reinstall within the pinned version constraint
+		var pinned InstallMethod
+		if pinned, err = method.pinned(tool.Constraint); err == nil {
+			err = runInstallMethod(ctx, tool, pinned, prefix, out, review)
+		}
+	case method.Type == "binary" || method.Type == "script":
+		err = runInstallMethod(ctx, tool, method, prefix, out, review)
+	case prefix != "":
+		err = installManaged(ctx, tool, method, prefix, true, out)
+	default:
+		var argv []string
+		if argv, err = method.upgradeCommand(); err == nil {
+			err = runCommand(ctx, out, argv)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return finishInstall(tool, newInstallRecord(method, prefix), out)
+}
+
+// describeUpgrade summarizes the versions reported by runUpgrade
+func describeUpgrade(before, after string) string {
+	switch {
+	case before == "" && after == "":
+		return "version unknown"
+	case before == after:
+		return fmt.Sprintf("already up to date (%s)", after)
+	}
+	return fmt.Sprintf("%s → %s", orDash(before), orDash(after))
+}
@@ -0,0 +1,690 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// validationIssue is a problem found in a catalog file
+type validationIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i validationIssue) String() string {
+	location := fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	if i.Field != "" {
+		return fmt.Sprintf("%s: %s: %s", location, i.Field, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+// jsonField is an object member together with its offsets in the source file
+type jsonField struct {
+	key      string
+	keyOff   int
+	value    json.RawMessage
+	valueOff int
+}
+
+// jsonElement is an array element together with its offset in the source file
+type jsonElement struct {
+	value json.RawMessage
+	off   int
+}
+
+// scannedEntry is a tool definition read from a catalog layer
+type scannedEntry struct {
+	fields map[string]json.RawMessage
+	keyOff map[string]int
+	off    int
+}
+
+var (
+	toolKeys   = jsonFieldNames(AITool{})
+	serverKeys = jsonFieldNames(MCPServerConfig{})
+	methodKeys = jsonFieldNames(InstallMethod{})
+	assetKeys  = jsonFieldNames(BinaryAsset{})
+	prereqKeys = jsonFieldNames(Prerequisite{})
+
+	shellPattern   = regexp.MustCompile(`^[\w.+-]+$`)
+	scpRepoPattern = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[\w.-]+/[\w.-]+$`)
+)
+
+func jsonFieldNames(v interface{}) map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// tokenStart skips whitespace and separators to find where the next token begins
+func tokenStart(data []byte, off int) int {
+	for off < len(data) {
+		switch data[off] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			off++
+		default:
+			return off
+		}
+	}
+	return off
+}
+
+func scanArray(data []byte, base int) ([]jsonElement, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected an array")
+	}
+
+	var elements []jsonElement
+	for dec.More() {
+		off := tokenStart(data, int(dec.InputOffset()))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		elements = append(elements, jsonElement{value: raw, off: base + off})
+	}
+	return elements, nil
+}
+
+func scanObject(data []byte, base int) ([]jsonField, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	var fields []jsonField
+	for dec.More() {
+		keyOff := tokenStart(data, int(dec.InputOffset()))
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		valueOff := tokenStart(data, int(dec.InputOffset()))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		fields = append(fields, jsonField{
+			key:      tok.(string),
+			keyOff:   base + keyOff,
+			value:    raw,
+			valueOff: base + valueOff,
+		})
+	}
+	return fields, nil
+}
+
+func lineColumn(data []byte, off int) (int, int) {
+	if off > len(data) {
+		off = len(data)
+	}
+	line := 1 + bytes.Count(data[:off], []byte("\n"))
+	column := off + 1
+	if i := bytes.LastIndexByte(data[:off], '\n'); i >= 0 {
+		column = off - i
+	}
+	return line, column
+}
+
+// layerValidator collects issues for a single catalog file
+type layerValidator struct {
+	file   string
+	data   []byte
+	issues []validationIssue
+}
+
+func (v *layerValidator) report(off int, field, format string, args ...interface{}) {
+	line, column := lineColumn(v.data, off)
+	v.issues = append(v.issues, validationIssue{
+		File:    v.file,
+		Line:    line,
+		Column:  column,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *layerValidator) reportJSONError(base int, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		v.report(base+int(syntaxErr.Offset), "", "invalid JSON: %v", syntaxErr)
+	case errors.As(err, &typeErr):
+		v.report(base+int(typeErr.Offset), typeErr.Field, "expected %s, got %s", typeErr.Type, typeErr.Value)
+	default:
+		v.report(base, "", "%v", err)
+	}
+}
+
+// scanLayer parses a catalog file, reporting syntax errors, wrong types,
+// unknown keys, duplicate names and malformed values with their positions.
+func scanLayer(file string, data []byte) ([]scannedEntry, []validationIssue) {
+	v := &layerValidator{file: file, data: data}
+
+	elements, ok := v.scanDocument()
+	if !ok {
+		return nil, v.issues
+	}
+
+	var entries []scannedEntry
+	names := make(map[string]bool)
+	for i, element := range elements {
+		fields, err := scanObject(element.value, element.off)
+		if err != nil {
+			v.report(element.off, "", "tool #%d: %v", i+1, err)
+			continue
+		}
+
+		entry := scannedEntry{
+			fields: make(map[string]json.RawMessage),
+			keyOff: make(map[string]int),
+			off:    element.off,
+		}
+		for _, field := range fields {
+			if _, dup := entry.fields[field.key]; dup {
+				v.report(field.keyOff, field.key, "duplicate key")
+			}
+			if !toolKeys[field.key] {
+				v.report(field.keyOff, field.key, "unknown key")
+			}
+			entry.fields[field.key] = field.value
+			entry.keyOff[field.key] = field.keyOff
+		}
+
+		var tool AITool
+		if err := json.Unmarshal(element.value, &tool); err != nil {
+			v.reportJSONError(element.off, err)
+			continue
+		}
+
+		if tool.Name == "" {
+			v.report(element.off, "name", "tool #%d has no name", i+1)
+			continue
+		}
+		if names[tool.Name] {
+			v.report(entry.keyOff["name"], "name", "duplicate tool name %q", tool.Name)
+		}
+		names[tool.Name] = true
+
+		if tool.VersionRegex != "" {
+			if _, err := regexp.Compile(tool.VersionRegex); err != nil {
+				v.report(entry.keyOff["version_regex"], "version_regex", "invalid regular expression: %v", err)
+			}
+		}
+
+		if tool.Timeout != "" {
+			if d, err := time.ParseDuration(tool.Timeout); err != nil || d < 0 {
+				v.report(entry.keyOff["timeout"], "timeout", "invalid duration %q (expected e.g. \"10m\")", tool.Timeout)
+			}
+		}
+
+		if tool.GitHubRepo != "" && !validRepoURL(tool.GitHubRepo) {
+			v.report(entry.keyOff["github_repo"], "github_repo", "malformed repository URL %q", tool.GitHubRepo)
+		}
+		if tool.GitHubCommit != "" && !fullCommitPattern.MatchString(tool.GitHubCommit) {
+			v.report(entry.keyOff["github_commit"], "github_commit", "expected a full 40 character commit SHA, got %q", tool.GitHubCommit)
+		}
+		if tool.ScriptSHA256 != "" && !sha256Pattern.MatchString(tool.ScriptSHA256) {
+			v.report(entry.keyOff["script_sha256"], "script_sha256", "expected a 64 character hex SHA-256, got %q", tool.ScriptSHA256)
+		}
+		for _, key := range []string{"github_ref", "github_commit", "script_sha256"} {
+			if _, ok := entry.fields[key]; ok && tool.GitHubRepo == "" {
+				v.report(entry.keyOff[key], key, "only applies to tools with a github_repo")
+			}
+		}
+
+		if raw, ok := entry.fields["mcp_servers"]; ok {
+			v.checkMCPServers(raw, fieldValueOff(fields, "mcp_servers"))
+		}
+		if raw, ok := entry.fields["install_methods"]; ok {
+			v.checkInstallMethods(raw, fieldValueOff(fields, "install_methods"))
+		}
+		if raw, ok := entry.fields["prerequisites"]; ok {
+			v.checkPrerequisites(raw, fieldValueOff(fields, "prerequisites"))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, v.issues
+}
+
+// scanDocument returns the tool entries of a catalog file. Legacy bare arrays
+// are accepted as well as current documents.
+func (v *layerValidator) scanDocument() ([]jsonElement, bool) {
+	start := tokenStart(v.data, 0)
+	if start < len(v.data) && v.data[start] == '[' {
+		elements, err := scanArray(v.data, 0)
+		if err != nil {
+			v.reportJSONError(start, err)
+			return nil, false
+		}
+		return elements, true
+	}
+
+	fields, err := scanObject(v.data, 0)
+	if err != nil {
+		v.reportJSONError(start, err)
+		return nil, false
+	}
+
+	var elements []jsonElement
+	foundVersion, foundTools := false, false
+	for _, field := range fields {
+		switch field.key {
+		case "schema_version":
+			foundVersion = true
+			var version int
+			if err := json.Unmarshal(field.value, &version); err != nil {
+				v.report(field.valueOff, field.key, "expected a number")
+			} else if version < 1 || version > currentSchemaVersion {
+				v.report(field.valueOff, field.key, "unsupported schema_version %d (supported up to %d)", version, currentSchemaVersion)
+				return nil, false
+			}
+		case "tools":
+			foundTools = true
+			elements, err = scanArray(field.value, field.valueOff)
+			if err != nil {
+				v.report(field.valueOff, field.key, "%v", err)
+				return nil, false
+			}
+		default:
+			v.report(field.keyOff, field.key, "unknown key")
+		}
+	}
+
+	if !foundVersion {
+		v.report(start, "schema_version", "missing required field")
+	}
+	if !foundTools {
+		v.report(start, "tools", "missing required field")
+	}
+	return elements, true
+}
+
+func fieldValueOff(fields []jsonField, key string) int {
+	for _, field := range fields {
+		if field.key == key {
+			return field.valueOff
+		}
+	}
+	return 0
+}
+
+func (v *layerValidator) checkMCPServers(raw json.RawMessage, off int) {
+	if string(raw) == "null" {
+		return
+	}
+	elements, err := scanArray(raw, off)
+	if err != nil {
+		v.report(off, "mcp_servers", "%v", err)
+		return
+	}
+
+	names := make(map[string]bool)
+	for i, element := range elements {
+		fields, err := scanObject(element.value, element.off)
+		if err != nil {
+			v.report(element.off, "mcp_servers", "server #%d: %v", i+1, err)
+			continue
+		}
+
+		var name, command string
+		nameOff := element.off
+		for _, field := range fields {
+			if !serverKeys[field.key] {
+				v.report(field.keyOff, "mcp_servers."+field.key, "unknown key")
+			}
+			switch field.key {
+			case "name":
+				json.Unmarshal(field.value, &name)
+				nameOff = field.keyOff
+			case "command":
+				json.Unmarshal(field.value, &command)
+			}
+		}
+
+		if name == "" {
+			v.report(element.off, "mcp_servers.name", "server #%d has no name", i+1)
+		} else if names[name] {
+			v.report(nameOff, "mcp_servers.name", "duplicate MCP server name %q", name)
+		}
+		names[name] = true
+
+		if command == "" {
+			v.report(element.off, "mcp_servers.command", "server %q has no command", name)
+		}
+	}
+}
+
+func (v *layerValidator) checkInstallMethods(raw json.RawMessage, off int) {
+	if string(raw) == "null" {
+		return
+	}
+	elements, err := scanArray(raw, off)
+	if err != nil {
+		v.report(off, "install_methods", "%v", err)
+		return
+	}
+
+	for i, element := range elements {
+		fields, err := scanObject(element.value, element.off)
+		if err != nil {
+			v.report(element.off, "install_methods", "method #%d: %v", i+1, err)
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if seen[field.key] {
+				v.report(field.keyOff, "install_methods."+field.key, "duplicate key")
+			}
+			seen[field.key] = true
+			if !methodKeys[field.key] {
+				v.report(field.keyOff, "install_methods."+field.key, "unknown key")
+			}
+		}
+
+		var method InstallMethod
+		if json.Unmarshal(element.value, &method) != nil {
+			// Type errors are reported when the whole tool is decoded
+			continue
+		}
+		if _, ok := installMethodTypes[method.Type]; !ok {
+			v.report(element.off, "install_methods.type", "unknown install method %q", method.Type)
+			continue
+		}
+		switch method.Type {
+		case "binary":
+			v.checkBinaryMethod(method, fields, element.off)
+		case "script":
+			if method.URL == "" {
+				v.report(element.off, "install_methods.url", "%s method has no url", method.Type)
+			}
+			if method.SHA256 != "" && !sha256Pattern.MatchString(method.SHA256) {
+				v.report(element.off, "install_methods.sha256", "%q is not a hex SHA-256", method.SHA256)
+			}
+			if method.Shell != "" && !shellPattern.MatchString(method.Shell) {
+				v.report(element.off, "install_methods.shell", "%q is not a command name", method.Shell)
+			}
+		default:
+			if method.Package == "" {
+				v.report(element.off, "install_methods.package", "%s method has no package", method.Type)
+			}
+		}
+	}
+}
+
+func (v *layerValidator) checkBinaryMethod(method InstallMethod, fields []jsonField, off int) {
+	fieldOff := func(key string) int {
+		for _, field := range fields {
+			if field.key == key {
+				return field.keyOff
+			}
+		}
+		return off
+	}
+	// A duplicated key decodes to its last value, which is the one checked
+	var assets *jsonField
+	for i := range fields {
+		if fields[i].key == "assets" {
+			assets = &fields[i]
+		}
+	}
+
+	if method.URL == "" && len(method.Assets) == 0 {
+		v.report(off, "install_methods.url", "binary method has no url or assets")
+	}
+	switch {
+	case method.SHA256 != "" && !sha256Pattern.MatchString(method.SHA256):
+		v.report(fieldOff("sha256"), "install_methods.sha256", "%q is not a hex SHA-256", method.SHA256)
+	case method.SHA256 == "" && method.URL != "":
+		v.report(off, "install_methods.sha256", "binary method has no sha256, so its download cannot be verified")
+	}
+	switch method.Archive {
+	case "", "tar.gz", "zip", "none":
+	default:
+		v.report(fieldOff("archive"), "install_methods.archive", "unknown archive type %q, expected tar.gz, zip or none", method.Archive)
+	}
+
+	if assets == nil || string(assets.value) == "null" {
+		return
+	}
+	elements, err := scanArray(assets.value, assets.valueOff)
+	if err != nil {
+		v.report(assets.valueOff, "install_methods.assets", "%v", err)
+		return
+	}
+	for i, element := range elements {
+		if i >= len(method.Assets) {
+			break
+		}
+		assetFields, err := scanObject(element.value, element.off)
+		if err != nil {
+			v.report(element.off, "install_methods.assets", "asset #%d: %v", i+1, err)
+			continue
+		}
+		for _, assetField := range assetFields {
+			if !assetKeys[assetField.key] {
+				v.report(assetField.keyOff, "install_methods.assets."+assetField.key, "unknown key")
+			}
+		}
+		asset := method.Assets[i]
+		if asset.OS == "" {
+			v.report(element.off, "install_methods.assets.os", "asset #%d has no os", i+1)
+		}
+		if asset.URL == "" {
+			v.report(element.off, "install_methods.assets.url", "asset #%d has no url", i+1)
+		}
+		if asset.SHA256 == "" {
+			v.report(element.off, "install_methods.assets.sha256", "asset #%d has no sha256, so its download cannot be verified", i+1)
+		} else if !sha256Pattern.MatchString(asset.SHA256) {
+			v.report(element.off, "install_methods.assets.sha256", "%q is not a hex SHA-256", asset.SHA256)
+		}
+	}
+}
+
+func (v *layerValidator) checkPrerequisites(raw json.RawMessage, off int) {
+	if string(raw) == "null" {
+		return
+	}
+	elements, err := scanArray(raw, off)
+	if err != nil {
+		v.report(off, "prerequisites", "%v", err)
+		return
+	}
+
+	for i, element := range elements {
+		fields, err := scanObject(element.value, element.off)
+		if err != nil {
+			v.report(element.off, "prerequisites", "prerequisite #%d: %v", i+1, err)
+			continue
+		}
+		for _, field := range fields {
+			if !prereqKeys[field.key] {
+				v.report(field.keyOff, "prerequisites."+field.key, "unknown key")
+			}
+		}
+
+		var p Prerequisite
+		if json.Unmarshal(element.value, &p) != nil {
+			continue
+		}
+		switch {
+		case p.Command == "" && p.Tool == "":
+			v.report(element.off, "prerequisites", "prerequisite #%d needs a command or a tool", i+1)
+		case p.Command != "" && p.Tool != "":
+			v.report(element.off, "prerequisites", "prerequisite #%d has both a command and a tool", i+1)
+		case p.Tool != "" && (p.MinVersion != "" || p.VersionCmd != ""):
+			v.report(element.off, "prerequisites", "min_version and version_cmd only apply to commands")
+		case p.MinVersion != "" && len(versionParts(p.MinVersion)) == 0:
+			v.report(element.off, "prerequisites.min_version", "invalid version %q", p.MinVersion)
+		}
+	}
+}
+
+func validRepoURL(repo string) bool {
+	if scpRepoPattern.MatchString(repo) {
+		return true
+	}
+	u, err := url.Parse(repo)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+	default:
+		return false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return len(parts) >= 2 && parts[0] != "" && parts[1] != ""
+}
+
+// checkRequired reports required fields missing from a fully merged tool
+func checkRequired(tool AITool) []string {
+	var missing []string
+	if tool.CLICommand == "" {
+		missing = append(missing, "cli_command")
+	}
+	if tool.InstallCmd == "" && len(tool.InstallMethods) == 0 && tool.GitHubRepo == "" {
+		missing = append(missing, "install_methods")
+	}
+	return missing
+}
+
+// validateCatalogFile checks a single file as a complete catalog
+func validateCatalogFile(file string, data []byte) []validationIssue {
+	entries, issues := scanLayer(file, data)
+	for _, entry := range entries {
+		issues = append(issues, requiredIssues(entry.fields, func(key string) (string, []byte, int) {
+			if off, ok := entry.keyOff[key]; ok {
+				return file, data, off
+			}
+			return file, data, entry.off
+		})...)
+	}
+	return issues
+}
+
+// validateOverrideFile checks the user or project catalog file as the
+// layer it is: its own fields are type-checked, and required fields are
+// checked on the tools it defines once merged with the layers below.
+func validateOverrideFile(file string, data []byte, below []catalogLayer) []validationIssue {
+	layer := catalogLayer{path: file, data: data}
+	entries, mergeIssues := mergeLayers(append(below[:len(below):len(below)], layer))
+
+	var issues []validationIssue
+	for _, issue := range mergeIssues {
+		if issue.File == file {
+			issues = append(issues, issue)
+		}
+	}
+	for _, entry := range entries {
+		tool, err := entryTool(entry.fields)
+		if entry.layer.path != file || err != nil || tool.Disabled {
+			continue
+		}
+		issues = append(issues, requiredIssues(entry.fields, entry.locate)...)
+	}
+	return issues
+}
+
+// requiredFieldKeys are the keys that can supply a required field, used to
+// find where an empty one was set
+var requiredFieldKeys = map[string][]string{
+	"install_methods": {"install_methods", "install_cmd", "github_repo"},
+}
+
+// requiredIssues reports the required fields missing from a tool. locate
+// returns where to report a key that is present but empty, or with "" where
+// the tool itself is defined.
+func requiredIssues(fields map[string]json.RawMessage, locate func(key string) (string, []byte, int)) []validationIssue {
+	tool, err := entryTool(fields)
+	if err != nil {
+		return nil
+	}
+
+	var issues []validationIssue
+	for _, field := range checkRequired(tool) {
+		keys, ok := requiredFieldKeys[field]
+		if !ok {
+			keys = []string{field}
+		}
+		at := ""
+		for _, key := range keys {
+			if _, ok := fields[key]; ok {
+				at = key
+				break
+			}
+		}
+		file, data, off := locate(at)
+		line, column := lineColumn(data, off)
+		issues = append(issues, validationIssue{
+			File:    file,
+			Line:    line,
+			Column:  column,
+			Field:   field,
+			Message: fmt.Sprintf("%q is missing required field", tool.Name),
+		})
+	}
+	return issues
+}
+
+func entryTool(fields map[string]json.RawMessage) (AITool, error) {
+	var tool AITool
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return tool, err
+	}
+	err = json.Unmarshal(data, &tool)
+	return tool, err
+}
+
+func (m Model) viewErrors() string {
+	var b strings.Builder
+	for _, issue := range m.issues {
+		b.WriteString(errorStyle.Render("✗ "))
+		b.WriteString(issue.String())
+		b.WriteString("\n")
+	}
+
+	options := "C: Continue with the tools that loaded • Q: Quit"
+	if len(m.tools) == 0 {
+		b.WriteString(errorStyle.Render("✗ No tools found in the tool catalog"))
+		b.WriteString("\n")
+		options = "Q: Quit"
+	}
+
+	return fmt.Sprintf(
+		"\n%s\n\n%s\n%s\n",
+		titleStyle.Render("AI CLI Manager - Catalog Errors"),
+		b.String(),
+		options,
+	)
+}
@@ -0,0 +1,132 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanLayerDuplicateAssets(t *testing.T) {
+	data := []byte(`{
+  "schema_version": 3,
+  "tools": [
+    {
+      "name": "Fake",
+      "cli_command": "fake",
+      "install_methods": [
+        {
+          "type": "binary",
+          "assets": [
+            {"os": "linux", "url": "https://example.com/a"},
+            {"os": "darwin", "url": "https://example.com/b"}
+          ],
+          "assets": [
+            {"os": "linux", "url": "https://example.com/c"}
+          ]
+        }
+      ]
+    }
+  ]
+}`)
+
+	_, issues := scanLayer("tools.json", data)
+	var found bool
+	for _, issue := range issues {
+		if issue.Field == "install_methods.assets" && strings.Contains(issue.Message, "duplicate key") {
+			found = true
+			if issue.Line != 14 {
+				t.Errorf("duplicate key reported on line %d, want 14", issue.Line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("duplicate assets key not reported, got %v", issues)
+	}
+}
+
+func TestValidateOverrideFile(t *testing.T) {
+	catalogHome(t)
+	names := builtinNames(t)
+	data := []byte(`{
+  "schema_version": 3,
+  "tools": [
+    {"name": "` + names[0] + `", "check_cmd": "x --version"},
+    {"name": "` + names[1] + `", "disabled": true},
+    {"name": "New Tool", "install_cmd": "npm install -g new-tool"},
+    {"name": "` + names[2] + `", "cli_command": 7}
+  ]
+}
+`)
+	if err := os.WriteFile(userToolsPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	below, ok := overrideBase(userToolsPath())
+	if !ok || len(below) != 1 || below[0].source != sourceBuiltin {
+		t.Fatalf("overrideBase = %v, %v; want the builtin layer", below, ok)
+	}
+	issues := validateOverrideFile(userToolsPath(), data, below)
+
+	var got []string
+	for _, issue := range issues {
+		if issue.File != userToolsPath() {
+			t.Errorf("issue outside the validated file: %s", issue)
+		}
+		got = append(got, fmt.Sprintf("%d:%s", issue.Line, issue.Field))
+	}
+	// Only the new tool lacks cli_command, and only the wrong type is
+	// reported for the override
+	want := []string{"7:cli_command", "6:cli_command"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("issues = %v, want lines and fields %v", issues, want)
+	}
+
+	if standalone := validateCatalogFile(userToolsPath(), data); len(standalone) <= len(issues) {
+		t.Errorf("as a standalone catalog, got only %v", standalone)
+	}
+}
+
+func TestOverrideBaseStandalone(t *testing.T) {
+	catalogHome(t)
+	other := filepath.Join(t.TempDir(), "tools.json")
+	if err := os.WriteFile(other, []byte(`{"schema_version": 3, "tools": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := overrideBase(other); ok {
+		t.Error("an unrelated file was treated as an override layer")
+	}
+}
+
+func TestScanLayerBinaryNeedsSHA256(t *testing.T) {
+	data := []byte(`{
+  "schema_version": 3,
+  "tools": [
+    {
+      "name": "Fake",
+      "cli_command": "fake",
+      "install_methods": [
+        {"type": "binary", "url": "https://example.com/fake"},
+        {
+          "type": "binary",
+          "assets": [
+            {"os": "linux", "url": "https://example.com/a", "sha256": "` + strings.Repeat("a", 64) + `"},
+            {"os": "darwin", "url": "https://example.com/b"}
+          ]
+        }
+      ]
+    }
+  ]
+}`)
+
+	_, issues := scanLayer("tools.json", data)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%d:%s", issue.Line, issue.Field))
+	}
+	want := []string{"8:install_methods.sha256", "13:install_methods.assets.sha256"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("issues = %v, want lines and fields %v", issues, want)
+	}
+}
@@ -0,0 +1,84 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// prefixVenv is the virtual environment of a tool installed into prefix
+func prefixVenv(prefix string) string {
+	return filepath.Join(prefix, "venv")
+}
+
+func venvBinDir(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts")
+	}
+	return filepath.Join(venv, "bin")
+}
+
+func findPython() (string, error) {
+	for _, name := range []string{"python3", "python"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("python3 not found, it is needed to create a virtual environment")
+}
+
+// canCreateVenv reports whether python can create virtual environments
+// with pip in them. Some distributions ship venv and ensurepip separately.
+func canCreateVenv() bool {
+	python, err := findPython()
+	if err != nil {
+		return false
+	}
+	return exec.Command(python, "-c", "import venv, ensurepip").Run() == nil
+}
+
+// ensureVenv creates a Python virtual environment unless it exists
+func ensureVenv(ctx context.Context, venv string, out io.Writer) error {
+	if pathExists(venv) {
+		return nil
+	}
+	python, err := findPython()
+	if err != nil {
+		return err
+	}
+	return runInDir(ctx, out, "", python, "-m", "venv", venv)
+}
+
+// isolatePip turns a pip method into one that keeps the tool's
+// dependencies apart from other tools. pip itself installs into a venv of
+// the tool's own; when no venv can be created, pipx or uv is used instead.
+func isolatePip(method InstallMethod) InstallMethod {
+	if method.Type != "pip" || canCreateVenv() {
+		return method
+	}
+	for _, alternative := range []string{"pipx", "uv"} {
+		if _, err := exec.LookPath(alternative); err == nil {
+			method.Type = alternative
+			return method
+		}
+	}
+	return method
+}
+
+// toolExecutable resolves name, a tool's command or the first word of its
+// check command, to the file that runs it. The tool's own command is
+// looked up in its recorded venv first, so it is found even when the
+// managed bin directory is not on PATH.
+func toolExecutable(tool AITool, name string) (string, error) {
+	if name == tool.CLICommand {
+		if record, ok := loadInstallState().Tools[tool.Name]; ok && record.Venv != "" {
+			if path, err := exec.LookPath(filepath.Join(venvBinDir(record.Venv), name)); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return lookCommand(name)
+}
@@ -0,0 +1,212 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Package registries queried for the latest published versions
+var (
+	npmRegistryURL = "https://registry.npmjs.org"
+	pypiURL        = "https://pypi.org/pypi"
+	cratesURL      = "https://crates.io/api/v1/crates"
+)
+
+var registryClient = &http.Client{Timeout: 10 * time.Second}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?([-+.][0-9A-Za-z.]+)?`)
+
+// extractVersion finds the version in the output of a tool's check command,
+// using the tool's version_regex when set. The first capture group is used
+// if the regex has one, otherwise the whole match.
+func extractVersion(tool AITool, output string) string {
+	pattern := versionPattern
+	if tool.VersionRegex != "" {
+		re, err := regexp.Compile(tool.VersionRegex)
+		if err != nil {
+			return ""
+		}
+		pattern = re
+	}
+
+	match := pattern.FindStringSubmatch(output)
+	if len(match) == 0 {
+		return ""
+	}
+	if pattern != versionPattern && len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
+
+// latestVersion asks the package manager the tool is installed with for the
+// newest available version.
+func latestVersion(tool AITool) (string, error) {
+	method, err := installedMethod(tool)
+	if err != nil {
+		return "", err
+	}
+
+	switch method.Type {
+	case "npm":
+		var info struct {
+			Version string `json:"version"`
+		}
+		err := getJSON(fmt.Sprintf("%s/%s/latest", npmRegistryURL, method.Package), &info)
+		return info.Version, err
+	case "pip", "pipx", "uv":
+		var info struct {
+			Info struct {
+				Version string `json:"version"`
+			} `json:"info"`
+		}
+		err := getJSON(fmt.Sprintf("%s/%s/json", pypiURL, packageName(method.Package)), &info)
+		return info.Info.Version, err
+	case "cargo":
+		var info struct {
+			Crate struct {
+				MaxStableVersion string `json:"max_stable_version"`
+			} `json:"crate"`
+		}
+		err := getJSON(fmt.Sprintf("%s/%s", cratesURL, method.Package), &info)
+		return info.Crate.MaxStableVersion, err
+	case "go":
+		module := strings.SplitN(method.Package, "@", 2)[0]
+		output, err := exec.Command("go", "list", "-m", "-f", "{{.Version}}", module+"@latest").Output()
+		return strings.TrimSpace(string(output)), err
+	case "brew":
+		return brewLatestVersion(method)
+	case "apt":
+		output, err := exec.Command("apt-cache", "policy", method.Package).Output()
+		if err != nil {
+			return "", err
+		}
+		for _, line := range strings.Split(string(output), "\n") {
+			if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "Candidate:" {
+				return fields[1], nil
+			}
+		}
+		return "", fmt.Errorf("no candidate version for %s", method.Package)
+	}
+	return "", fmt.Errorf("%s installs cannot be checked for updates", method.Type)
+}
+
+func getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "ai-cli-manager")
+
+	resp, err := registryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// packageName strips version specifiers and extras from a pip requirement
+func packageName(requirement string) string {
+	if i := strings.IndexAny(requirement, "[=<>!~ "); i >= 0 {
+		return requirement[:i]
+	}
+	return requirement
+}
+
+func brewLatestVersion(method InstallMethod) (string, error) {
+	output, err := exec.Command("brew", "info", "--json=v2", method.Package).Output()
+	if err != nil {
+		return "", err
+	}
+
+	var info struct {
+		Formulae []struct {
+			Versions struct {
+				Stable string `json:"stable"`
+			} `json:"versions"`
+		} `json:"formulae"`
+		Casks []struct {
+			Version string `json:"version"`
+		} `json:"casks"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return "", err
+	}
+	if len(info.Formulae) > 0 {
+		return info.Formulae[0].Versions.Stable, nil
+	}
+	if len(info.Casks) > 0 {
+		return info.Casks[0].Version, nil
+	}
+	return "", fmt.Errorf("no version found for %s", method.Package)
+}
+
+// compareVersions compares dotted version strings numerically, returning
+// -1, 0 or 1. A leading "v" and pre-release or build suffixes are ignored.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// isOutdated reports whether a newer version than the installed one is known
+func isOutdated(tool AITool) bool {
+	return tool.Installed && tool.Version != "" && tool.Latest != "" &&
+		compareVersions(tool.Version, tool.Latest) < 0
+}
+
+// checkLatestVersions looks up the latest version of every installed tool
+func checkLatestVersions(tools []AITool) map[string]string {
+	latest := make(map[string]string)
+	for _, tool := range tools {
+		if !tool.Installed {
+			continue
+		}
+		if version, err := latestVersion(tool); err == nil && version != "" {
+			latest[tool.Name] = version
+		}
+	}
+	return latest
+}
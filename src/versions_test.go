@@ -0,0 +1,103 @@
+package src
+
+import "testing"
+
+func TestExtractVersion(t *testing.T) {
+	tests := []struct {
+		regex  string
+		output string
+		want   string
+	}{
+		{"", "claude 1.0.43 (Claude Code)", "1.0.43"},
+		{"", "tool v2.3.1\n", "2.3.1"},
+		{"", "aider 0.86.1-dev", "0.86.1-dev"},
+		{"", "tool 1.0.0+build.5 linux", "1.0.0+build.5"},
+		{"", "version 1.2", "1.2"},
+		{"", "no version here", ""},
+		{`Version: (\S+)`, "Build 7\nVersion: 3.0.0-rc.1\n", "3.0.0-rc.1"},
+		{`v\d+\.\d+\.\d+`, "tool v4.5.6 (go1.22)", "v4.5.6"},
+		{`(\d+)\.(\d+)`, "tool 7.8.9", "7"},
+		{`Version: (\S+)`, "tool 1.0.0", ""},
+		{`(`, "tool 1.0.0", ""},
+	}
+	for _, tt := range tests {
+		got := extractVersion(AITool{VersionRegex: tt.regex}, tt.output)
+		if got != tt.want {
+			t.Errorf("extractVersion(%q, %q) = %q, want %q", tt.regex, tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.0.0", "1.2", 0},
+		{"1.2", "1.2.1", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"v1.2.3", "1.2.3", 0},
+		{"v2.0.0", "v10.0.0", -1},
+		{"1.2.3-beta.1", "1.2.3", 0},
+		{"1.2.3+build.5", "1.2.4", -1},
+		{" 1.2.3 ", "1.2.3", 0},
+		{"2", "1.99", 1},
+		{"", "0.0.1", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestVersionParts(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []int
+	}{
+		{"1.2.3", []int{1, 2, 3}},
+		{"v0.9", []int{0, 9}},
+		{"2.0.0-rc.1", []int{2, 0, 0}},
+		{"1.2.x", []int{1, 2}},
+		{"latest", nil},
+	}
+	for _, tt := range tests {
+		got := versionParts(tt.version)
+		if len(got) != len(tt.want) {
+			t.Errorf("versionParts(%q) = %v, want %v", tt.version, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("versionParts(%q) = %v, want %v", tt.version, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestIsOutdated(t *testing.T) {
+	tests := []struct {
+		tool AITool
+		want bool
+	}{
+		{AITool{Installed: true, Version: "1.2.0", Latest: "1.3.0"}, true},
+		{AITool{Installed: true, Version: "v1.3.0", Latest: "1.3.0"}, false},
+		{AITool{Installed: true, Version: "1.3", Latest: "1.3.0"}, false},
+		{AITool{Installed: true, Version: "2.0.0", Latest: "1.9.0"}, false},
+		{AITool{Installed: true, Version: "", Latest: "1.3.0"}, false},
+		{AITool{Installed: true, Version: "1.2.0", Latest: ""}, false},
+		{AITool{Installed: false, Version: "1.2.0", Latest: "1.3.0"}, false},
+	}
+	for _, tt := range tests {
+		if got := isOutdated(tt.tool); got != tt.want {
+			t.Errorf("isOutdated(%+v) = %v, want %v", tt.tool, got, tt.want)
+		}
+	}
+}
@@ -4,15 +4,15 @@ echo "Testing JSON-based tool loading..."
 echo ""
 
 # Check if JSON file exists and is valid
-if [ ! -f "ai_tools.json" ]; then
-    echo "❌ ai_tools.json not found"
+if [ ! -f "src/ai_tools.json" ]; then
+    echo "❌ src/ai_tools.json not found"
     exit 1
 fi
 
-echo "✅ ai_tools.json found"
+echo "✅ src/ai_tools.json found"
 
 # Check JSON validity
-if jq empty ai_tools.json; then
+if jq empty src/ai_tools.json; then
     echo "✅ JSON is valid"
 else
     echo "❌ JSON is invalid"
@@ -20,20 +20,20 @@ else
 fi
 
 # Count tools
-TOOL_COUNT=$(jq length ai_tools.json)
+TOOL_COUNT=$(jq '.tools | length' src/ai_tools.json)
 echo "✅ Found $TOOL_COUNT tools in JSON"
 
 # Show first 5 tool names
 echo ""
 echo "First 5 tools:"
-jq -r '.[0:5][] | "  • " + .name' ai_tools.json
+jq -r '.tools[0:5][] | "  • " + .name' src/ai_tools.json
 
 # Show tools with MCP servers
 echo ""
 echo "Tools with MCP servers:"
-jq -r '.[] | select(.mcp_servers != null) | "  • " + .name + " (" + (.mcp_servers | length | tostring) + " servers)"' ai_tools.json
+jq -r '.tools[] | select(.mcp_servers != null) | "  • " + .name + " (" + (.mcp_servers | length | tostring) + " servers)"' src/ai_tools.json
 
 echo ""
 echo "✅ JSON configuration is ready!"
 echo ""
-echo "The application will load these tools from ai_tools.json when run."
\ No newline at end of file
+echo "These tools are embedded into the binary at build time."
\ No newline at end of file
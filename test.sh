@@ -29,11 +29,29 @@ find src -name "*.go" | while read file; do
     echo "  - $file"
 done
 
+# The MCP config file, resolved the way the app does: the environment, then
+# mcp_config_path in config.json, then Claude Desktop's location for this OS
+MCP_CONFIG="$AI_CLI_MANAGER_MCP_CONFIG"
+MCP_FROM="from AI_CLI_MANAGER_MCP_CONFIG"
+if [ -z "$MCP_CONFIG" ] && [ -f "$HOME/.ai-cli-manager/config.json" ]; then
+    MCP_CONFIG=$(sed -n 's/.*"mcp_config_path"[[:space:]]*:[[:space:]]*"\([^"]*\)".*/\1/p' "$HOME/.ai-cli-manager/config.json" | head -n 1)
+    MCP_FROM="from config.json"
+fi
+if [ -z "$MCP_CONFIG" ]; then
+    case "$(uname -s)" in
+        Darwin) CONFIG_DIR="$HOME/Library/Application Support" ;;
+        MINGW*|MSYS*|CYGWIN*) CONFIG_DIR="$APPDATA" ;;
+        *) CONFIG_DIR="${XDG_CONFIG_HOME:-$HOME/.config}" ;;
+    esac
+    MCP_CONFIG="$CONFIG_DIR/Claude/claude_desktop_config.json"
+    MCP_FROM="detected"
+fi
+
 echo ""
 echo "Configuration will be stored in:"
-echo "  - ~/.ai-cli-manager/tools.json (tool definitions)"
+echo "  - ~/.ai-cli-manager/tools.json (user tool overrides)"
 echo "  - ~/.ai-cli-manager/config.json (GitHub settings)"
-echo "  - ~/Library/Application Support/Claude/claude_desktop_config.json (MCP servers)"
+echo "  - $MCP_CONFIG (MCP servers, $MCP_FROM)"
 
 echo ""
 echo "To run the application in a terminal:"